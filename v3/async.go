@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/opentracing/opentracing-go"
 	"runtime/debug"
+	"runtime/pprof"
 )
 
 type AsyncExecutor struct {
@@ -11,6 +12,7 @@ type AsyncExecutor struct {
 
 func (p AsyncExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
 	go func() {
+		pprof.SetGoroutineLabels(asyncTaskLabels)
 		defer func() {
 			if r := recover(); r != nil {
 				resultChn <- TaskResult{
@@ -27,6 +29,13 @@ func (p AsyncExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId in
 			c = spanCtx
 			defer span.Finish()
 		}
+		if labels := taskLabels(false, opt, taskId, "async", ""); labels != nil {
+			resultChn <- TaskResult{
+				err: runLabelled(c, taskFunc, append(labels, labelKey, "async-task"), asyncTaskLabels),
+				id:  taskId,
+			}
+			return
+		}
 		resultChn <- TaskResult{
 			err: taskFunc(c),
 			id:  taskId,
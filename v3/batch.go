@@ -0,0 +1,177 @@
+package koncurrent
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// BatchFunc handles a batch of items. It returns either one error per item, in the same order as
+// the items, or a non nil error failing the whole batch. A nil slice means every item succeeded.
+type BatchFunc func(ctx context.Context, items []interface{}) ([]error, error)
+
+type BatchOptions struct {
+	// MaxSize is the number of items that triggers a batch right away. Defaults to 100.
+	MaxSize int
+	// MaxWait is how long the first item of a batch waits for more items. Defaults to 10ms.
+	MaxWait time.Duration
+	Clock   Clock
+}
+
+// BatchingExecutor collects the items of the tasks created by Task and hands them to a BatchFunc in
+// batches, which runs on the given executor. Each task receives the outcome of its own item. The
+// batch function gets a context that is never done, with the values of the context of the first
+// item of the batch; the values of the contexts of the other items do not reach it.
+type BatchingExecutor struct {
+	executor  TaskExecutor
+	batchFunc BatchFunc
+	options   BatchOptions
+	mu        sync.Mutex
+	current   *pendingBatch
+}
+
+type pendingBatch struct {
+	items []batchItem
+	stop  chan struct{}
+}
+
+type batchItem struct {
+	ctx       context.Context
+	value     interface{}
+	resultChn chan TaskResult
+}
+
+// batchItemExecutor runs the tasks of the items on goroutines of their own, where they wait for the
+// outcome of their batch.
+type batchItemExecutor struct {
+	batching *BatchingExecutor
+}
+
+func NewBatchingExecutor(executor TaskExecutor, batchFunc BatchFunc, options BatchOptions) *BatchingExecutor {
+	if options.MaxSize <= 0 {
+		options.MaxSize = 100
+	}
+	if options.MaxWait <= 0 {
+		options.MaxWait = 10 * time.Millisecond
+	}
+	if options.Clock == nil {
+		options.Clock = SystemClock{}
+	}
+	return &BatchingExecutor{
+		executor:  executor,
+		batchFunc: batchFunc,
+		options:   options,
+	}
+}
+
+// Task returns a task that completes once the batch containing item has been handled. The task
+// function adds the item to a batch and waits for its outcome, so wrappers such as Timeout and Retry
+// apply to the item: a retried item is added to a later batch, and an item that times out is
+// dropped from its batch if the batch has not started yet.
+func (b *BatchingExecutor) Task(item interface{}) TaskExecution {
+	return TaskExecution{
+		taskFunc: func(ctx context.Context) error {
+			resultChn := make(chan TaskResult, 1)
+			b.add(batchItem{
+				ctx:       ctx,
+				value:     item,
+				resultChn: resultChn,
+			})
+			select {
+			case result := <-resultChn:
+				return result.err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+		executor: batchItemExecutor{
+			batching: b,
+		},
+	}
+}
+
+func (e batchItemExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	AsyncExecutor{}.Execute(ctx, taskFunc, taskId, resultChn, opt)
+}
+
+func (b *BatchingExecutor) add(item batchItem) {
+	b.mu.Lock()
+	if b.current == nil {
+		b.current = &pendingBatch{
+			stop: make(chan struct{}),
+		}
+		go b.flushAfterMaxWait(b.current)
+	}
+	batch := b.current
+	batch.items = append(batch.items, item)
+	if len(batch.items) < b.options.MaxSize {
+		b.mu.Unlock()
+		return
+	}
+	b.current = nil
+	b.mu.Unlock()
+	close(batch.stop)
+	go b.run(batch.items)
+}
+
+func (b *BatchingExecutor) flushAfterMaxWait(batch *pendingBatch) {
+	pprof.SetGoroutineLabels(batchLabels)
+	timer := b.options.Clock.NewTimer(b.options.MaxWait)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+	case <-batch.stop:
+		return
+	}
+	b.mu.Lock()
+	if b.current != batch {
+		b.mu.Unlock()
+		return
+	}
+	b.current = nil
+	b.mu.Unlock()
+	b.run(batch.items)
+}
+
+func (b *BatchingExecutor) run(items []batchItem) {
+	pprof.SetGoroutineLabels(batchLabels)
+	live := items[:0]
+	for _, item := range items {
+		if err := item.ctx.Err(); err != nil {
+			item.resultChn <- TaskResult{
+				err: err,
+			}
+		} else {
+			live = append(live, item)
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+	values := make([]interface{}, len(live))
+	for i := range live {
+		values[i] = live[i].value
+	}
+	var itemErrs []error
+	resultChn := make(chan TaskResult, 1)
+	b.executor.Execute(detachedContext{live[0].ctx}, func(ctx context.Context) error {
+		errs, err := b.batchFunc(ctx, values)
+		if err == nil && errs != nil && len(errs) != len(values) {
+			err = fmt.Errorf("batch function returned %d errors for %d items", len(errs), len(values))
+		}
+		itemErrs = errs
+		return err
+	}, 0, resultChn, TaskExecutionOptions{})
+	batchErr := (<-resultChn).err
+	for i, item := range live {
+		err := batchErr
+		if err == nil && itemErrs != nil {
+			err = itemErrs[i]
+		}
+		item.resultChn <- TaskResult{
+			err: err,
+		}
+	}
+}
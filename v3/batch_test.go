@@ -0,0 +1,137 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchingExecutor_MaxSize(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]interface{}
+	testErr := errors.New("test")
+	underTest := NewBatchingExecutor(AsyncExecutor{}, func(ctx context.Context, items []interface{}) ([]error, error) {
+		mu.Lock()
+		batches = append(batches, items)
+		mu.Unlock()
+		errs := make([]error, len(items))
+		for i := range items {
+			if items[i] == "b" {
+				errs[i] = testErr
+			}
+		}
+		return errs, nil
+	}, BatchOptions{MaxSize: 3, MaxWait: time.Hour})
+	results, err := ExecuteParallel(underTest.Task("a"), underTest.Task("b"), underTest.Task("c")).Await(context.Background())
+	assertEqual(t, testErr, err)
+	assertNil(t, results[0][0])
+	assertEqual(t, testErr, results[0][1])
+	assertNil(t, results[0][2])
+	assertEqual(t, 1, len(batches))
+	assertEqual(t, 3, len(batches[0]))
+}
+
+func TestBatchingExecutor_MaxWait(t *testing.T) {
+	clock := newFakeClock()
+	calls := make(chan []interface{}, 1)
+	testErr := errors.New("test")
+	underTest := NewBatchingExecutor(ImmediateExecutor{}, func(ctx context.Context, items []interface{}) ([]error, error) {
+		calls <- items
+		return nil, testErr
+	}, BatchOptions{MaxSize: 10, MaxWait: 5 * time.Millisecond, Clock: clock})
+	resultChn := make(chan TaskResult, 2)
+	for i, task := range []TaskExecution{underTest.Task(1), underTest.Task(2)} {
+		task.executor.Execute(context.Background(), task.taskFunc, i, resultChn, TaskExecutionOptions{})
+	}
+	clock.blockUntil(1)
+	underTest.waitPending(2)
+	select {
+	case <-calls:
+		t.Error("batch ran before max wait")
+	default:
+	}
+	clock.Advance(5 * time.Millisecond)
+	assertEqual(t, 2, len(<-calls))
+	assertEqual(t, testErr, (<-resultChn).err)
+	assertEqual(t, testErr, (<-resultChn).err)
+}
+
+func TestBatchingExecutor_Mismatch(t *testing.T) {
+	underTest := NewBatchingExecutor(AsyncExecutor{}, func(ctx context.Context, items []interface{}) ([]error, error) {
+		return []error{nil}, nil
+	}, BatchOptions{MaxSize: 2})
+	_, err := ExecuteParallel(underTest.Task(1), underTest.Task(2)).Await(context.Background())
+	assertEqual(t, "batch function returned 1 errors for 2 items", errors.Unwrap(err).Error())
+
+	underTest = NewBatchingExecutor(AsyncExecutor{}, func(ctx context.Context, items []interface{}) ([]error, error) {
+		panic("test panic")
+	}, BatchOptions{MaxSize: 1})
+	_, err = ExecuteSerial(underTest.Task(1)).Await(context.Background())
+	_, ok := err.(PanicError)
+	assertTrue(t, ok)
+}
+
+// waitPending waits until the pending batch has n items, the tasks adding them from goroutines of
+// their own.
+func (b *BatchingExecutor) waitPending(n int) {
+	for {
+		b.mu.Lock()
+		pending := 0
+		if b.current != nil {
+			pending = len(b.current.items)
+		}
+		b.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBatchingExecutor_Retry(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]interface{}
+	errUnavailable := errors.New("unavailable")
+	underTest := NewBatchingExecutor(ImmediateExecutor{}, func(ctx context.Context, items []interface{}) ([]error, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, items)
+		if len(batches) == 1 {
+			return nil, errUnavailable
+		}
+		return nil, nil
+	}, BatchOptions{MaxSize: 1})
+	_, err := ExecuteSerial(underTest.Task("a").Retry(RetryPolicy{Attempts: 2})).Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 2, len(batches))
+}
+
+func TestBatchingExecutor_Timeout(t *testing.T) {
+	clock := newFakeClock()
+	var mu sync.Mutex
+	var batches [][]interface{}
+	underTest := NewBatchingExecutor(ImmediateExecutor{}, func(ctx context.Context, items []interface{}) ([]error, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, items)
+		return nil, nil
+	}, BatchOptions{MaxSize: 3, MaxWait: time.Hour, Clock: clock})
+	go func() {
+		// the timers of the timeout and of the batch
+		clock.blockUntil(2)
+		clock.Advance(time.Minute)
+	}()
+	_, err := ExecuteSerial(underTest.Task("a").Timeout(time.Minute)).Await(WithClock(context.Background(), clock))
+	assertEqual(t, context.DeadlineExceeded, err)
+
+	// the batch drops the item that timed out when the items filling it up flush it
+	_, err = ExecuteParallel(underTest.Task("b"), underTest.Task("c")).Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 1, len(batches))
+	assertEqual(t, 2, len(batches[0]))
+	for _, item := range batches[0] {
+		assertTrue(t, item != "a")
+	}
+}
@@ -0,0 +1,243 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+type BulkheadFullError struct {
+	Bulkhead string
+}
+
+func (e BulkheadFullError) Error() string {
+	return "bulkhead " + e.Bulkhead + " is full"
+}
+
+func (e BulkheadFullError) Is(target error) bool {
+	return target == ErrBulkheadFull
+}
+
+type BulkheadOptions struct {
+	// MaxConcurrent is the number of tasks the bulkhead runs at once on its own capacity. Defaults to 1.
+	MaxConcurrent int
+	// MaxQueue is the number of tasks that may wait for capacity before new tasks are rejected.
+	MaxQueue int
+	// Borrow lets the bulkhead run extra tasks on the idle capacity of the group that is not reserved
+	// by the MaxConcurrent of its bulkheads.
+	Borrow bool
+}
+
+type BulkheadStats struct {
+	Name          string
+	MaxConcurrent int
+	MaxQueue      int
+	Active        int
+	Borrowed      int
+	Queued        int
+	Completed     uint64
+	Rejected      uint64
+	// Cancelled is the number of tasks whose context was done while they were queued, which are not
+	// counted as completed.
+	Cancelled uint64
+}
+
+// Bulkheads partitions the capacity of a single executor, usually a PoolExecutor whose pool size is
+// the given capacity, into named bulkheads. Queued tasks are handed to the executor by the worker of
+// the task that releases their capacity, so the executor must accept up to capacity tasks without
+// blocking: a PoolExecutor needs a queue size of at least the capacity.
+type Bulkheads struct {
+	executor  TaskExecutor
+	capacity  int
+	mu        sync.Mutex
+	reserved  int
+	borrowed  int
+	bulkheads []*Bulkhead
+}
+
+type Bulkhead struct {
+	group     *Bulkheads
+	name      string
+	options   BulkheadOptions
+	active    int
+	borrowed  int
+	queue     []bulkheadTask
+	completed uint64
+	rejected  uint64
+	cancelled uint64
+}
+
+type bulkheadTask struct {
+	ctx       context.Context
+	taskFunc  TaskFunc
+	taskId    int
+	resultChn chan TaskResult
+	opt       TaskExecutionOptions
+	borrowed  bool
+}
+
+// NewBulkheads panics if executor is a PoolExecutor whose queue is smaller than capacity.
+func NewBulkheads(executor TaskExecutor, capacity int) *Bulkheads {
+	if pe, ok := executor.(PoolExecutor); ok && cap(pe.queue) < capacity {
+		panic(fmt.Sprintf("bulkheads of capacity %d need a pool queue of at least that size, found %d", capacity, cap(pe.queue)))
+	}
+	return &Bulkheads{
+		executor: executor,
+		capacity: capacity,
+	}
+}
+
+// Bulkhead returns the bulkhead with the given name, creating it with the given options if it does
+// not exist yet. It panics if the new bulkhead would bring the sum of the MaxConcurrent of the
+// bulkheads over the capacity of the group.
+func (g *Bulkheads) Bulkhead(name string, options BulkheadOptions) *Bulkhead {
+	if options.MaxConcurrent <= 0 {
+		options.MaxConcurrent = 1
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, b := range g.bulkheads {
+		if b.name == name {
+			return b
+		}
+	}
+	if g.reserved+options.MaxConcurrent > g.capacity {
+		panic(fmt.Sprintf("bulkhead %s: MaxConcurrent %d exceeds the %d unreserved capacity of the group", name, options.MaxConcurrent, g.capacity-g.reserved))
+	}
+	g.reserved += options.MaxConcurrent
+	b := &Bulkhead{
+		group:   g,
+		name:    name,
+		options: options,
+	}
+	g.bulkheads = append(g.bulkheads, b)
+	return b
+}
+
+func (g *Bulkheads) Stats() []BulkheadStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ret := make([]BulkheadStats, len(g.bulkheads))
+	for i, b := range g.bulkheads {
+		ret[i] = b.stats()
+	}
+	return ret
+}
+
+func (b *Bulkhead) Stats() BulkheadStats {
+	b.group.mu.Lock()
+	defer b.group.mu.Unlock()
+	return b.stats()
+}
+
+func (b *Bulkhead) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	task := bulkheadTask{
+		ctx:       ctx,
+		taskFunc:  taskFunc,
+		taskId:    taskId,
+		resultChn: resultChn,
+		opt:       opt,
+	}
+	g := b.group
+	g.mu.Lock()
+	if len(b.queue) == 0 && b.tryAcquire(&task) {
+		g.mu.Unlock()
+		b.dispatch(task)
+		return
+	}
+	if len(b.queue) >= b.options.MaxQueue {
+		b.rejected++
+		g.mu.Unlock()
+		resultChn <- TaskResult{
+			err: BulkheadFullError{Bulkhead: b.name},
+			id:  taskId,
+		}
+		return
+	}
+	b.queue = append(b.queue, task)
+	g.mu.Unlock()
+}
+
+func (b *Bulkhead) stats() BulkheadStats {
+	return BulkheadStats{
+		Name:          b.name,
+		MaxConcurrent: b.options.MaxConcurrent,
+		MaxQueue:      b.options.MaxQueue,
+		Active:        b.active,
+		Borrowed:      b.borrowed,
+		Queued:        len(b.queue),
+		Completed:     b.completed,
+		Rejected:      b.rejected,
+		Cancelled:     b.cancelled,
+	}
+}
+
+// tryAcquire takes a slot of the capacity reserved by the bulkhead, or else borrows one of the
+// capacity no bulkhead reserved. The reserved capacity of the other bulkheads stays available to
+// them whatever the borrowers do.
+func (b *Bulkhead) tryAcquire(task *bulkheadTask) bool {
+	g := b.group
+	if b.active-b.borrowed < b.options.MaxConcurrent {
+		task.borrowed = false
+	} else if b.options.Borrow && g.borrowed < g.capacity-g.reserved {
+		task.borrowed = true
+		b.borrowed++
+		g.borrowed++
+	} else {
+		return false
+	}
+	b.active++
+	return true
+}
+
+func (b *Bulkhead) dispatch(task bulkheadTask) {
+	if err := task.ctx.Err(); err != nil {
+		b.release(task.borrowed, true)
+		task.resultChn <- TaskResult{
+			err: err,
+			id:  task.taskId,
+		}
+		return
+	}
+	taskFunc := task.taskFunc
+	borrowed := task.borrowed
+	b.group.executor.Execute(task.ctx, func(ctx context.Context) error {
+		defer b.release(borrowed, false)
+		return taskFunc(ctx)
+	}, task.taskId, task.resultChn, task.opt)
+}
+
+func (b *Bulkhead) release(borrowed bool, cancelled bool) {
+	g := b.group
+	g.mu.Lock()
+	b.active--
+	if borrowed {
+		b.borrowed--
+		g.borrowed--
+	}
+	if cancelled {
+		b.cancelled++
+	} else {
+		b.completed++
+	}
+	type pending struct {
+		bulkhead *Bulkhead
+		task     bulkheadTask
+	}
+	var next []pending
+	// the releasing bulkhead is drained first so that its own capacity goes back to it
+	for _, candidate := range append([]*Bulkhead{b}, g.bulkheads...) {
+		for len(candidate.queue) > 0 && candidate.tryAcquire(&candidate.queue[0]) {
+			next = append(next, pending{bulkhead: candidate, task: candidate.queue[0]})
+			candidate.queue[0] = bulkheadTask{}
+			candidate.queue = candidate.queue[1:]
+		}
+	}
+	g.mu.Unlock()
+	for _, p := range next {
+		p.bulkhead.dispatch(p.task)
+	}
+}
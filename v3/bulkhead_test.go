@@ -0,0 +1,148 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBulkhead_Execute(t *testing.T) {
+	pe := NewPoolExecutor(2, 2)
+	defer pe.Close()
+	underTest := NewBulkheads(pe, 2)
+	slow := underTest.Bulkhead("slow", BulkheadOptions{MaxConcurrent: 1, MaxQueue: 1})
+	fast := underTest.Bulkhead("fast", BulkheadOptions{MaxConcurrent: 1})
+	assertTrue(t, slow == underTest.Bulkhead("slow", BulkheadOptions{}))
+
+	block := make(chan struct{})
+	started := make(chan struct{}, 3)
+	var blocking TaskFunc = func(ctx context.Context) error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}
+	resultChn := make(chan TaskResult, 4)
+	slow.Execute(context.Background(), blocking, 0, resultChn, TaskExecutionOptions{})
+	slow.Execute(context.Background(), blocking, 1, resultChn, TaskExecutionOptions{})
+	slow.Execute(context.Background(), blocking, 2, resultChn, TaskExecutionOptions{})
+	<-started
+	result := <-resultChn
+	assertEqual(t, 2, result.id)
+	assertTrue(t, errors.Is(result.err, ErrBulkheadFull))
+	assertEqual(t, "bulkhead slow is full", result.err.Error())
+
+	stats := slow.Stats()
+	assertEqual(t, 1, stats.Active)
+	assertEqual(t, 1, stats.Queued)
+	assertEqual(t, uint64(1), stats.Rejected)
+
+	// the slow bulkhead is saturated but the fast one still has its own capacity
+	_, err := ExecuteSerial(TaskFunc(func(ctx context.Context) error {
+		return nil
+	}).Executor(fast)).Await(context.Background())
+	assertNil(t, err)
+
+	close(block)
+	<-resultChn
+	<-resultChn
+	stats = underTest.Stats()[0]
+	assertEqual(t, 0, stats.Active)
+	assertEqual(t, 0, stats.Queued)
+	assertEqual(t, uint64(2), stats.Completed)
+}
+
+func TestBulkhead_Borrow(t *testing.T) {
+	pe := NewPoolExecutor(4, 4)
+	defer pe.Close()
+	underTest := NewBulkheads(pe, 4)
+	borrower := underTest.Bulkhead("borrower", BulkheadOptions{MaxConcurrent: 1, Borrow: true})
+	idle := underTest.Bulkhead("idle", BulkheadOptions{MaxConcurrent: 2})
+
+	block := make(chan struct{})
+	started := make(chan struct{}, 4)
+	var blocking TaskFunc = func(ctx context.Context) error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}
+	resultChn := make(chan TaskResult, 5)
+	for i := 0; i < 3; i++ {
+		borrower.Execute(context.Background(), blocking, i, resultChn, TaskExecutionOptions{})
+	}
+	for i := 0; i < 2; i++ {
+		<-started
+	}
+	// only the capacity no bulkhead reserved can be borrowed
+	result := <-resultChn
+	assertEqual(t, 2, result.id)
+	assertTrue(t, errors.Is(result.err, ErrBulkheadFull))
+	stats := borrower.Stats()
+	assertEqual(t, 2, stats.Active)
+	assertEqual(t, 1, stats.Borrowed)
+
+	// the capacity reserved by the idle bulkhead is still there for it
+	idle.Execute(context.Background(), blocking, 3, resultChn, TaskExecutionOptions{})
+	idle.Execute(context.Background(), blocking, 4, resultChn, TaskExecutionOptions{})
+	for i := 0; i < 2; i++ {
+		<-started
+	}
+	assertEqual(t, 2, idle.Stats().Active)
+	close(block)
+	for i := 0; i < 4; i++ {
+		assertNil(t, (<-resultChn).err)
+	}
+}
+
+func TestBulkhead_Cancelled(t *testing.T) {
+	pe := NewPoolExecutor(1, 1)
+	defer pe.Close()
+	underTest := NewBulkheads(pe, 1)
+	bulkhead := underTest.Bulkhead("orders", BulkheadOptions{MaxConcurrent: 1, MaxQueue: 1})
+
+	block := make(chan struct{})
+	started := make(chan struct{}, 1)
+	resultChn := make(chan TaskResult, 2)
+	bulkhead.Execute(context.Background(), func(ctx context.Context) error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}, 0, resultChn, TaskExecutionOptions{})
+	<-started
+	ctx, cancel := context.WithCancel(context.Background())
+	bulkhead.Execute(ctx, func(ctx context.Context) error {
+		return nil
+	}, 1, resultChn, TaskExecutionOptions{})
+	cancel()
+	close(block)
+	// the queued task is cancelled by the worker releasing the capacity, before its own result
+	results := map[int]error{}
+	for i := 0; i < 2; i++ {
+		result := <-resultChn
+		results[result.id] = result.err
+	}
+	assertNil(t, results[0])
+	assertEqual(t, context.Canceled, results[1])
+	stats := bulkhead.Stats()
+	assertEqual(t, uint64(1), stats.Completed)
+	assertEqual(t, uint64(1), stats.Cancelled)
+}
+
+func TestBulkheads_Overcommitted(t *testing.T) {
+	pe := NewPoolExecutor(2, 2)
+	defer pe.Close()
+	underTest := NewBulkheads(pe, 2)
+	underTest.Bulkhead("orders", BulkheadOptions{MaxConcurrent: 2})
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	underTest.Bulkhead("reports", BulkheadOptions{MaxConcurrent: 1})
+}
+
+func TestNewBulkheads_SmallQueue(t *testing.T) {
+	pe := NewPoolExecutor(2, 1)
+	defer pe.Close()
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	NewBulkheads(pe, 2)
+}
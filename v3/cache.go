@@ -0,0 +1,176 @@
+package koncurrent
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// TaskCache stores the outcome of cached tasks. A nil error is a cached success.
+type TaskCache interface {
+	Get(key string) (err error, ok bool)
+	Set(key string, err error, ttl time.Duration)
+}
+
+var DefaultTaskCache TaskCache = NewLRUCache(1024, nil)
+
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// LRUCache is an in-process TaskCache holding up to size entries, evicting the least recently used.
+// The cached tasks missing the same key at the same time are coalesced into a single run.
+type LRUCache struct {
+	mu        sync.Mutex
+	size      int
+	clock     Clock
+	entries   *list.List
+	items     map[string]*list.Element
+	hits      uint64
+	misses    uint64
+	evictions uint64
+	coalesce  coalesceGroup
+}
+
+type lruEntry struct {
+	key     string
+	err     error
+	expires time.Time
+}
+
+func NewLRUCache(size int, clock Clock) *LRUCache {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &LRUCache{
+		size:    size,
+		clock:   clock,
+		entries: list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(key string) (error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !c.clock.Now().Before(entry.expires) {
+		c.entries.Remove(elem)
+		delete(c.items, key)
+		c.misses++
+		return nil, false
+	}
+	c.entries.MoveToFront(elem)
+	c.hits++
+	return entry.err, true
+}
+
+func (c *LRUCache) Set(key string, err error, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.clock.Now().Add(ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.err = err
+		entry.expires = expires
+		c.entries.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.entries.PushFront(&lruEntry{
+		key:     key,
+		err:     err,
+		expires: expires,
+	})
+	for c.entries.Len() > c.size {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+		c.evictions++
+	}
+}
+
+func (c *LRUCache) coalescer() *coalesceGroup {
+	return &c.coalesce
+}
+
+func (c *LRUCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.entries.Len(),
+	}
+}
+
+type cacheExecutor struct {
+	executor TaskExecutor
+	cache    TaskCache
+	key      string
+	ttl      time.Duration
+	errTTL   time.Duration
+}
+
+// Cache serves the outcome of the task from DefaultTaskCache for ttl after it succeeded, skipping the
+// task function entirely. Only the error outcome is cached, so the task is expected to leave any
+// value it produces somewhere it outlives the execution.
+func (t TaskExecution) Cache(key string, ttl time.Duration) TaskExecution {
+	return t.CacheWith(DefaultTaskCache, key, ttl, 0)
+}
+
+// CacheWith is like Cache but uses the given cache, and also caches failures for errTTL when it is
+// positive.
+func (t TaskExecution) CacheWith(cache TaskCache, key string, ttl time.Duration, errTTL time.Duration) TaskExecution {
+	ret := t
+	ret.executor = cacheExecutor{
+		executor: t.executor,
+		cache:    cache,
+		key:      key,
+		ttl:      ttl,
+		errTTL:   errTTL,
+	}
+	return ret
+}
+
+func (c cacheExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	if err, ok := c.cache.Get(c.key); ok {
+		if opt.observer != nil {
+			opt.emit(EventCacheHit, taskId, 0, err)
+		}
+		resultChn <- TaskResult{
+			err: err,
+			id:  taskId,
+		}
+		return
+	}
+	if opt.observer != nil {
+		opt.emit(EventCacheMiss, taskId, 0, nil)
+	}
+	run := func(ctx context.Context) error {
+		err := taskFunc(ctx)
+		if ctx.Err() != nil {
+			return err
+		}
+		if err == nil {
+			c.cache.Set(c.key, nil, c.ttl)
+		} else if c.errTTL > 0 {
+			c.cache.Set(c.key, err, c.errTTL)
+		}
+		return err
+	}
+	if cache, ok := c.cache.(coalescing); ok {
+		cache.coalescer().execute(ctx, c.key, c.executor, run, taskId, resultChn, opt)
+		return
+	}
+	c.executor.Execute(ctx, run, taskId, resultChn, opt)
+}
@@ -0,0 +1,123 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	clock := newFakeClock()
+	underTest := NewLRUCache(2, clock)
+	testErr := errors.New("test")
+	underTest.Set("a", nil, time.Minute)
+	underTest.Set("b", testErr, time.Second)
+	err, ok := underTest.Get("b")
+	assertTrue(t, ok)
+	assertEqual(t, testErr, err)
+	_, ok = underTest.Get("a")
+	assertTrue(t, ok)
+	underTest.Set("c", nil, time.Minute)
+	_, ok = underTest.Get("b")
+	assertTrue(t, !ok)
+	clock.Advance(time.Minute)
+	_, ok = underTest.Get("a")
+	assertTrue(t, !ok)
+	stats := underTest.Stats()
+	assertEqual(t, uint64(2), stats.Hits)
+	assertEqual(t, uint64(2), stats.Misses)
+	assertEqual(t, uint64(1), stats.Evictions)
+	assertEqual(t, 1, stats.Size)
+}
+
+func TestTaskExecution_Cache(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewLRUCache(10, clock)
+	var calls int32
+	testErr := errors.New("test")
+	fail := true
+	var lookup TaskFunc = func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		if fail {
+			return testErr
+		}
+		return nil
+	}
+	_, err := ExecuteSerial(lookup.Immediate().CacheWith(cache, "k", time.Minute, 0)).Await(context.Background())
+	assertEqual(t, testErr, err)
+	fail = false
+	recorder := NewRecorder()
+	for i := 0; i < 3; i++ {
+		_, err = ExecuteSerial(lookup.Immediate().CacheWith(cache, "k", time.Minute, 0)).
+			Observe(recorder).
+			Await(context.Background())
+		assertNil(t, err)
+	}
+	assertEqual(t, int32(2), atomic.LoadInt32(&calls))
+	assertTrue(t, recorder.Records()[0].CacheHit)
+	clock.Advance(time.Minute)
+	_, err = ExecuteSerial(lookup.Immediate().CacheWith(cache, "k", time.Minute, 0)).Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, int32(3), atomic.LoadInt32(&calls))
+
+	fail = true
+	for i := 0; i < 2; i++ {
+		_, err = ExecuteSerial(lookup.Immediate().CacheWith(cache, "neg", time.Minute, time.Second)).Await(context.Background())
+		assertEqual(t, testErr, err)
+	}
+	assertEqual(t, int32(4), atomic.LoadInt32(&calls))
+	assertEqual(t, uint64(3), cache.Stats().Hits)
+}
+
+func TestTaskExecution_CacheCoalescesMisses(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	var lookup TaskFunc = func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	}
+	// the immediate tasks of a parallel stage are submitted in order, so every lookup has missed the
+	// cache by the time the last task releases them
+	var releasing TaskFunc = func(ctx context.Context) error {
+		close(release)
+		return nil
+	}
+	cache := NewLRUCache(8, nil)
+	_, err := ExecuteParallel(
+		lookup.Immediate().CacheWith(cache, "k", time.Minute, 0),
+		lookup.Immediate().CacheWith(cache, "k", time.Minute, 0),
+		lookup.Immediate().CacheWith(cache, "k", time.Minute, 0),
+		releasing.Immediate(),
+	).Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, int32(1), atomic.LoadInt32(&calls))
+	assertEqual(t, uint64(3), cache.Stats().Misses)
+}
+
+type mapCache map[string]error
+
+func (c mapCache) Get(key string) (error, bool) {
+	err, ok := c[key]
+	return err, ok
+}
+
+func (c mapCache) Set(key string, err error, ttl time.Duration) {
+	c[key] = err
+}
+
+func TestTaskExecution_CacheWithoutCoalescing(t *testing.T) {
+	var calls int32
+	var lookup TaskFunc = func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+	cache := mapCache{}
+	for i := 0; i < 2; i++ {
+		_, err := ExecuteSerial(lookup.Immediate().CacheWith(cache, "k", time.Minute, 0)).Await(context.Background())
+		assertNil(t, err)
+	}
+	assertEqual(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,174 @@
+package koncurrent
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CheckpointStore keeps the names of the tasks of an execution that succeeded, so that an Await
+// resuming the execution skips them. Implementations must be safe for concurrent use.
+type CheckpointStore interface {
+	// Load returns the names of the tasks of the execution saved so far.
+	Load(executionID string) ([]string, error)
+	// Save records that the task of the execution succeeded.
+	Save(executionID string, task string) error
+	// Delete forgets the tasks of the execution.
+	Delete(executionID string) error
+}
+
+type checkpoint struct {
+	store CheckpointStore
+	id    string
+}
+
+// Checkpoint saves the tasks of the execution that succeed to the store under the execution id.
+// An Await of an execution with the same id, after a failed or interrupted one, skips the tasks
+// saved and resumes from the stage that did not finish. The tasks are saved by name, or by their
+// position in the execution if they have none, so the names must be unique within the execution.
+// A task that could not be saved fails with the error of the store. The checkpoints are kept once
+// the execution succeeds, until they are deleted from the store.
+func (e Execution) Checkpoint(store CheckpointStore, executionID string) Execution {
+	ret := e
+	ret.checkpoint = &checkpoint{
+		store: store,
+		id:    executionID,
+	}
+	return ret
+}
+
+type MemoryCheckpointStore struct {
+	mu    sync.Mutex
+	tasks map[string][]string
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{
+		tasks: make(map[string][]string),
+	}
+}
+
+func (s *MemoryCheckpointStore) Load(executionID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.tasks[executionID]...), nil
+}
+
+func (s *MemoryCheckpointStore) Save(executionID string, task string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[executionID] = append(s.tasks[executionID], task)
+	return nil
+}
+
+func (s *MemoryCheckpointStore) Delete(executionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tasks, executionID)
+	return nil
+}
+
+// FileCheckpointStore keeps the tasks of every execution in a file of its directory, one quoted
+// task name per line. Each Save appends a line and syncs the file, and Load ignores a last line cut
+// short by a crash.
+type FileCheckpointStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileCheckpointStore returns a store keeping its files in dir, which is created if needed.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileCheckpointStore{
+		dir: dir,
+	}, nil
+}
+
+func (s *FileCheckpointStore) path(executionID string) string {
+	return filepath.Join(s.dir, url.PathEscape(executionID)+".checkpoint")
+}
+
+func (s *FileCheckpointStore) Load(executionID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return readQuotedLines(s.path(executionID))
+}
+
+func (s *FileCheckpointStore) Save(executionID string, task string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return appendQuotedLine(s.path(executionID), task)
+}
+
+func (s *FileCheckpointStore) Delete(executionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(executionID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// readQuotedLines reads a file of quoted strings, one per line, ignoring a last line cut short by a
+// crash. A missing file has no lines.
+func readQuotedLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	// the last element is empty, or a line cut short by a crash
+	lines = lines[:len(lines)-1]
+	ret := make([]string, len(lines))
+	for i, line := range lines {
+		if ret[i], err = strconv.Unquote(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid line %s", path, i+1, line)
+		}
+	}
+	return ret, nil
+}
+
+// appendQuotedLine appends the quoted string to the file, creating it if needed, and syncs it. A
+// last line cut short by a crash is cut off first, so that the new line does not extend it.
+func appendQuotedLine(path string, s string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	if err = truncateCutLine(f); err == nil {
+		if _, err = f.WriteString(strconv.Quote(s) + "\n"); err == nil {
+			err = f.Sync()
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// truncateCutLine truncates the file after its last newline, unless it already ends with one.
+func truncateCutLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err = f.ReadAt(last, info.Size()-1); err != nil || last[0] == '\n' {
+		return err
+	}
+	data := make([]byte, info.Size())
+	if _, err = f.ReadAt(data, 0); err != nil {
+		return err
+	}
+	return f.Truncate(int64(strings.LastIndexByte(string(data), '\n') + 1))
+}
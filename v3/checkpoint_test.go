@@ -0,0 +1,195 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type countingTasks struct {
+	mu   sync.Mutex
+	runs map[string]int
+	fail map[string]bool
+}
+
+func (c *countingTasks) task(name string) TaskExecution {
+	var taskFunc TaskFunc = func(ctx context.Context) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.runs[name]++
+		if c.fail[name] {
+			return errors.New(name + " failed")
+		}
+		return nil
+	}
+	return taskFunc.Immediate().Name(name)
+}
+
+func TestExecution_Checkpoint(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	tasks := &countingTasks{
+		runs: map[string]int{},
+		fail: map[string]bool{"pay": true, "invoice": true},
+	}
+	execution := func() Execution {
+		return ExecuteSerial(tasks.task("reserve"), tasks.task("pay"), tasks.task("ship")).
+			ExecuteParallel(tasks.task("notify"), tasks.task("invoice"), tasks.task("archive")).
+			Checkpoint(store, "order-42")
+	}
+
+	_, err := execution().Await(context.Background())
+	assertNotNil(t, err)
+	saved, _ := store.Load("order-42")
+	assertEqual(t, "reserve", strings.Join(saved, ","))
+
+	// the serial stage resumes from the task that failed
+	tasks.fail["pay"] = false
+	_, err = execution().Await(context.Background())
+	assertNotNil(t, err)
+	assertEqual(t, 1, tasks.runs["reserve"])
+	assertEqual(t, 2, tasks.runs["pay"])
+	assertEqual(t, 1, tasks.runs["ship"])
+	assertEqual(t, 1, tasks.runs["notify"])
+
+	// the parallel stage runs only the task that failed
+	tasks.fail["invoice"] = false
+	results, err := execution().Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 0, len(results.FlattenErrors()))
+	assertEqual(t, 1, tasks.runs["ship"])
+	assertEqual(t, 1, tasks.runs["notify"])
+	assertEqual(t, 2, tasks.runs["invoice"])
+	assertEqual(t, 1, tasks.runs["archive"])
+
+	// another execution id starts over
+	_, err = execution().Checkpoint(store, "order-43").Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 2, tasks.runs["reserve"])
+}
+
+type failingCheckpointStore struct {
+	MemoryCheckpointStore
+}
+
+var errStoreDown = errors.New("store down")
+
+func (s *failingCheckpointStore) Save(executionID string, task string) error {
+	return errStoreDown
+}
+
+func TestExecution_CheckpointSaveFailed(t *testing.T) {
+	tasks := &countingTasks{runs: map[string]int{}}
+	results, err := ExecuteSerial(tasks.task("reserve"), tasks.task("pay")).
+		Checkpoint(&failingCheckpointStore{}, "order-42").
+		Await(context.Background())
+	assertTrue(t, errors.Is(err, errStoreDown))
+	assertTrue(t, errors.Is(results[0][0], errStoreDown))
+	assertEqual(t, 0, tasks.runs["pay"])
+}
+
+func TestExecution_CheckpointCompensate(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	store.Save("order-42", "reserve")
+	tasks := &countingTasks{runs: map[string]int{}, fail: map[string]bool{"pay": true}}
+	_, err := ExecuteSerial(
+		tasks.task("reserve").Compensate(func(ctx context.Context) error {
+			return nil
+		}, RetryPolicy{}),
+		tasks.task("pay"),
+	).Checkpoint(store, "order-42").Await(context.Background())
+
+	// the task saved by an earlier Await is compensated too
+	assertEqual(t, 0, tasks.runs["reserve"])
+	assertEqual(t, 1, len(err.(SagaError).Compensations))
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+	store, err := NewFileCheckpointStore(dir)
+	assertNil(t, err)
+	tasks, err := store.Load("nightly/2026-10-19")
+	assertNil(t, err)
+	assertEqual(t, 0, len(tasks))
+
+	assertNil(t, store.Save("nightly/2026-10-19", "extract"))
+	assertNil(t, store.Save("nightly/2026-10-19", "load\nusers"))
+	tasks, err = store.Load("nightly/2026-10-19")
+	assertNil(t, err)
+	assertEqual(t, 2, len(tasks))
+	assertEqual(t, "extract", tasks[0])
+	assertEqual(t, "load\nusers", tasks[1])
+
+	// a line cut short by a crash is ignored
+	path := filepath.Join(dir, "nightly%2F2026-10-19.checkpoint")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assertNil(t, err)
+	f.WriteString(`"trans`)
+	f.Close()
+	tasks, err = store.Load("nightly/2026-10-19")
+	assertNil(t, err)
+	assertEqual(t, 2, len(tasks))
+
+	// and cut off by the next save
+	assertNil(t, store.Save("nightly/2026-10-19", "transform"))
+	tasks, err = store.Load("nightly/2026-10-19")
+	assertNil(t, err)
+	assertEqual(t, 3, len(tasks))
+	assertEqual(t, "transform", tasks[2])
+
+	assertNil(t, store.Delete("nightly/2026-10-19"))
+	assertNil(t, store.Delete("nightly/2026-10-19"))
+	tasks, err = store.Load("nightly/2026-10-19")
+	assertNil(t, err)
+	assertEqual(t, 0, len(tasks))
+}
+
+func TestFileCheckpointStore_Resume(t *testing.T) {
+	store, err := NewFileCheckpointStore(t.TempDir())
+	assertNil(t, err)
+	tasks := &countingTasks{runs: map[string]int{}, fail: map[string]bool{"load": true}}
+	execution := ExecuteSerial(tasks.task("extract"), tasks.task("load")).Checkpoint(store, "nightly")
+	_, err = execution.Await(context.Background())
+	assertNotNil(t, err)
+
+	// a new store on the same directory, as after a restart
+	store, err = NewFileCheckpointStore(store.dir)
+	assertNil(t, err)
+	tasks.fail["load"] = false
+	_, err = execution.Checkpoint(store, "nightly").Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 1, tasks.runs["extract"])
+	assertEqual(t, 2, tasks.runs["load"])
+}
+
+func TestExecution_CheckpointResumeParallelCompensate(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	store.Save("order-42", "reserve")
+	compensations := 0
+	tasks := &countingTasks{runs: map[string]int{}, fail: map[string]bool{"pay": true}}
+	recorder := NewRecorder()
+	_, err := ExecuteParallel(
+		tasks.task("reserve").Compensate(func(ctx context.Context) error {
+			compensations++
+			return nil
+		}, RetryPolicy{}),
+		tasks.task("quote"),
+	).ExecuteSerial(tasks.task("pay")).
+		Checkpoint(store, "order-42").
+		Observe(recorder).
+		Await(context.Background())
+
+	// the skipped task is compensated once and neither saved again nor reported as a result
+	assertEqual(t, 0, tasks.runs["reserve"])
+	assertEqual(t, 1, tasks.runs["quote"])
+	assertEqual(t, 1, len(err.(SagaError).Compensations))
+	assertEqual(t, 1, compensations)
+	saved, _ := store.Load("order-42")
+	assertEqual(t, "reserve,quote", strings.Join(saved, ","))
+	for _, record := range recorder.Records() {
+		assertTrue(t, record.Name != "reserve")
+	}
+}
@@ -0,0 +1,123 @@
+package koncurrent
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type chromeTraceEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat,omitempty"`
+	Ph   string                 `json:"ph"`
+	Ts   float64                `json:"ts"`
+	Dur  float64                `json:"dur,omitempty"`
+	Pid  int                    `json:"pid"`
+	Tid  uint64                 `json:"tid"`
+	ID   int                    `json:"id,omitempty"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the recorded tasks as a Chrome Trace Event JSON timeline, which opens in
+// about:tracing and Perfetto. Every goroutine that ran a task is a track with a slice per attempt
+// of a task, and the time tasks spent queued between being submitted and starting is shown as
+// async "queue" slices.
+func (r *Recorder) WriteChromeTrace(w io.Writer) error {
+	records := r.Records()
+	var origin time.Time
+	for _, record := range records {
+		for _, t := range []time.Time{record.Submitted, record.Started} {
+			if !t.IsZero() && (origin.IsZero() || t.Before(origin)) {
+				origin = t
+			}
+		}
+	}
+	ts := func(t time.Time) float64 {
+		return float64(t.Sub(origin).Nanoseconds()) / 1e3
+	}
+	events := []chromeTraceEvent{}
+	goroutines := map[uint64]bool{}
+	for i, record := range records {
+		name := taskName(record.Stage, record.Task, record.Name)
+		args := map[string]interface{}{
+			"stage": record.Stage,
+			"task":  record.Task,
+		}
+		if !record.Submitted.IsZero() && !record.Started.IsZero() {
+			events = append(events, chromeTraceEvent{
+				Name: "queue " + name,
+				Cat:  "queue",
+				Ph:   "b",
+				Ts:   ts(record.Submitted),
+				Pid:  1,
+				ID:   i + 1,
+				Args: args,
+			}, chromeTraceEvent{
+				Name: "queue " + name,
+				Cat:  "queue",
+				Ph:   "e",
+				Ts:   ts(record.Started),
+				Pid:  1,
+				ID:   i + 1,
+			})
+		}
+		for _, run := range record.Runs {
+			if run.Finished.IsZero() {
+				continue
+			}
+			goroutines[run.Goroutine] = true
+			runArgs := map[string]interface{}{
+				"stage":   record.Stage,
+				"task":    record.Task,
+				"attempt": run.Attempt,
+			}
+			if run.Err != nil {
+				runArgs["error"] = run.Err.Error()
+			}
+			events = append(events, chromeTraceEvent{
+				Name: name,
+				Cat:  "task",
+				Ph:   "X",
+				Ts:   ts(run.Started),
+				Dur:  ts(run.Finished) - ts(run.Started),
+				Pid:  1,
+				Tid:  run.Goroutine,
+				Args: runArgs,
+			})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Ts < events[j].Ts
+	})
+	metadata := []chromeTraceEvent{{
+		Name: "process_name",
+		Ph:   "M",
+		Pid:  1,
+		Args: map[string]interface{}{"name": "koncurrent"},
+	}}
+	ids := make([]uint64, 0, len(goroutines))
+	for id := range goroutines {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		metadata = append(metadata, chromeTraceEvent{
+			Name: "thread_name",
+			Ph:   "M",
+			Pid:  1,
+			Tid:  id,
+			Args: map[string]interface{}{"name": "goroutine " + strconv.FormatUint(id, 10)},
+		})
+	}
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+		DisplayTimeUnit string             `json:"displayTimeUnit"`
+	}{
+		TraceEvents:     append(metadata, events...),
+		DisplayTimeUnit: "ms",
+	})
+}
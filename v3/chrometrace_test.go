@@ -0,0 +1,68 @@
+package koncurrent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestRecorder_WriteChromeTrace(t *testing.T) {
+	pe := NewPoolExecutor(2, 2)
+	defer pe.Close()
+	var succeeding TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var failing TaskFunc = func(ctx context.Context) error {
+		return errors.New("test")
+	}
+	recorder := NewRecorder()
+	_, err := ExecuteParallel(succeeding.Pool(pe).Name("load"), succeeding.Async()).
+		ExecuteSerial(failing.Immediate().Name("save")).
+		Observe(recorder).
+		Await(context.Background())
+	assertNotNil(t, err)
+
+	var buf bytes.Buffer
+	assertNil(t, recorder.WriteChromeTrace(&buf))
+	var timeline struct {
+		TraceEvents []struct {
+			Name string                 `json:"name"`
+			Cat  string                 `json:"cat"`
+			Ph   string                 `json:"ph"`
+			Ts   float64                `json:"ts"`
+			Tid  uint64                 `json:"tid"`
+			Args map[string]interface{} `json:"args"`
+		} `json:"traceEvents"`
+	}
+	assertNil(t, json.Unmarshal(buf.Bytes(), &timeline))
+	slices := map[string]uint64{}
+	threads := map[uint64]bool{}
+	queued := 0
+	for _, event := range timeline.TraceEvents {
+		switch event.Ph {
+		case "X":
+			slices[event.Name] = event.Tid
+			if event.Name == "save" {
+				assertEqual(t, "test", event.Args["error"])
+				assertEqual(t, float64(1), event.Args["stage"])
+			}
+		case "M":
+			if event.Name == "thread_name" {
+				threads[event.Tid] = true
+			}
+		case "b":
+			queued++
+			assertTrue(t, event.Ts >= 0)
+		}
+	}
+	assertEqual(t, 3, len(slices))
+	assertEqual(t, 3, queued)
+	for _, name := range []string{"load", "stage0-task1", "save"} {
+		tid, ok := slices[name]
+		assertTrue(t, ok)
+		assertTrue(t, threads[tid])
+	}
+	assertTrue(t, slices["load"] != slices["stage0-task1"])
+}
@@ -0,0 +1,239 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before allowing trial requests. Defaults to 30s.
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests is the number of trial requests allowed while half-open; that many
+	// successes close the circuit again. Defaults to 1.
+	HalfOpenMaxRequests int
+	// IsFailure decides whether a task error counts as a failure. Defaults to err != nil.
+	IsFailure func(err error) bool
+	// OnStateChange is called after a breaker changes state.
+	OnStateChange func(name string, from CircuitState, to CircuitState)
+	Clock         Clock
+}
+
+type CircuitBreakerExecutor struct {
+	executor TaskExecutor
+	options  CircuitBreakerOptions
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+type circuitBreaker struct {
+	state      CircuitState
+	generation int
+	failures   int
+	successes  int
+	inFlight   int
+	openedAt   time.Time
+}
+
+type circuitStateChange struct {
+	from CircuitState
+	to   CircuitState
+}
+
+func NewCircuitBreakerExecutor(executor TaskExecutor, options CircuitBreakerOptions) *CircuitBreakerExecutor {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 5
+	}
+	if options.OpenTimeout <= 0 {
+		options.OpenTimeout = 30 * time.Second
+	}
+	if options.HalfOpenMaxRequests <= 0 {
+		options.HalfOpenMaxRequests = 1
+	}
+	if options.Clock == nil {
+		options.Clock = SystemClock{}
+	}
+	if options.IsFailure == nil {
+		options.IsFailure = func(err error) bool {
+			return err != nil
+		}
+	}
+	return &CircuitBreakerExecutor{
+		executor: executor,
+		options:  options,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+func (c *CircuitBreakerExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	name := opt.circuitBreakerName
+	generation, trial, ok := c.acquire(name)
+	if !ok {
+		resultChn <- TaskResult{
+			err: ErrCircuitOpen,
+			id:  taskId,
+		}
+		return
+	}
+	var started int32
+	wrapped := func(ctx context.Context) (err error) {
+		atomic.StoreInt32(&started, 1)
+		defer func() {
+			if r := recover(); r != nil {
+				c.release(name, generation, PanicError{})
+				panic(r)
+			}
+		}()
+		err = taskFunc(ctx)
+		c.release(name, generation, err)
+		return err
+	}
+	if !trial {
+		c.executor.Execute(ctx, wrapped, taskId, resultChn, opt)
+		return
+	}
+	// the executor may report the trial without running it, when it rejects it or its context is
+	// done, which must free its slot
+	trialResultChn := make(chan TaskResult, 1)
+	c.executor.Execute(ctx, wrapped, taskId, trialResultChn, opt)
+	go func() {
+		pprof.SetGoroutineLabels(breakerLabels)
+		taskResult := <-trialResultChn
+		if atomic.LoadInt32(&started) == 0 {
+			c.abandon(name, generation)
+		}
+		resultChn <- taskResult
+	}()
+}
+
+func (c *CircuitBreakerExecutor) State(name string) CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[name]
+	if !ok {
+		return CircuitClosed
+	}
+	if b.state == CircuitOpen && c.options.Clock.Now().Sub(b.openedAt) >= c.options.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// acquire returns the generation of the breaker, whether the task is a trial of a half-open breaker,
+// and whether it may run.
+func (c *CircuitBreakerExecutor) acquire(name string) (int, bool, bool) {
+	c.mu.Lock()
+	b, ok := c.breakers[name]
+	if !ok {
+		b = &circuitBreaker{}
+		c.breakers[name] = b
+	}
+	var changes []circuitStateChange
+	if b.state == CircuitOpen && c.options.Clock.Now().Sub(b.openedAt) >= c.options.OpenTimeout {
+		changes = append(changes, b.transit(CircuitHalfOpen, c.options.Clock))
+	}
+	allowed, trial := true, false
+	switch b.state {
+	case CircuitOpen:
+		allowed = false
+	case CircuitHalfOpen:
+		if b.inFlight+b.successes >= c.options.HalfOpenMaxRequests {
+			allowed = false
+		} else {
+			b.inFlight++
+			trial = true
+		}
+	}
+	generation := b.generation
+	c.mu.Unlock()
+	c.notify(name, changes)
+	return generation, trial, allowed
+}
+
+// abandon frees the slot of a trial that did not run, without counting an outcome.
+func (c *CircuitBreakerExecutor) abandon(name string, generation int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.breakers[name]
+	if b.generation == generation && b.state == CircuitHalfOpen {
+		b.inFlight--
+	}
+}
+
+func (c *CircuitBreakerExecutor) release(name string, generation int, err error) {
+	failed := c.options.IsFailure(err)
+	c.mu.Lock()
+	b := c.breakers[name]
+	if b.generation != generation {
+		c.mu.Unlock()
+		return
+	}
+	var changes []circuitStateChange
+	switch b.state {
+	case CircuitClosed:
+		if !failed {
+			b.failures = 0
+		} else if b.failures++; b.failures >= c.options.FailureThreshold {
+			changes = append(changes, b.transit(CircuitOpen, c.options.Clock))
+		}
+	case CircuitHalfOpen:
+		b.inFlight--
+		if failed {
+			changes = append(changes, b.transit(CircuitOpen, c.options.Clock))
+		} else if b.successes++; b.successes >= c.options.HalfOpenMaxRequests {
+			changes = append(changes, b.transit(CircuitClosed, c.options.Clock))
+		}
+	}
+	c.mu.Unlock()
+	c.notify(name, changes)
+}
+
+func (c *CircuitBreakerExecutor) notify(name string, changes []circuitStateChange) {
+	if c.options.OnStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		c.options.OnStateChange(name, change.from, change.to)
+	}
+}
+
+func (b *circuitBreaker) transit(to CircuitState, clock Clock) circuitStateChange {
+	change := circuitStateChange{from: b.state, to: to}
+	b.state = to
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.inFlight = 0
+	if to == CircuitOpen {
+		b.openedAt = clock.Now()
+	}
+	return change
+}
@@ -0,0 +1,121 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerExecutor_Execute(t *testing.T) {
+	var changes []CircuitState
+	clock := newFakeClock()
+	underTest := NewCircuitBreakerExecutor(ImmediateExecutor{}, CircuitBreakerOptions{
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+		Clock:            clock,
+		OnStateChange: func(name string, from CircuitState, to CircuitState) {
+			assertEqual(t, "db", name)
+			changes = append(changes, to)
+		},
+	})
+	calls := 0
+	var failing TaskFunc = func(ctx context.Context) error {
+		calls++
+		return errors.New("test")
+	}
+	var succeeding TaskFunc = func(ctx context.Context) error {
+		calls++
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		_, err := failing.Executor(underTest).CircuitBreaker("db").Execution().Await(context.Background())
+		assertNotNil(t, err)
+	}
+	assertEqual(t, CircuitOpen, underTest.State("db"))
+	assertEqual(t, CircuitClosed, underTest.State("other"))
+
+	results, err := succeeding.Executor(underTest).CircuitBreaker("db").Execution().Await(context.Background())
+	assertTrue(t, errors.Is(err, ErrCircuitOpen))
+	assertTrue(t, errors.Is(results[0][0], ErrCircuitOpen))
+	assertEqual(t, 2, calls)
+
+	clock.Advance(50 * time.Millisecond)
+	assertEqual(t, CircuitHalfOpen, underTest.State("db"))
+	_, err = succeeding.Executor(underTest).CircuitBreaker("db").Execution().Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 3, calls)
+	assertEqual(t, CircuitClosed, underTest.State("db"))
+	assertEqual(t, 3, len(changes))
+	assertEqual(t, CircuitOpen, changes[0])
+	assertEqual(t, CircuitHalfOpen, changes[1])
+	assertEqual(t, CircuitClosed, changes[2])
+}
+
+func TestCircuitBreakerExecutor_HalfOpenFailureReopens(t *testing.T) {
+	clock := newFakeClock()
+	underTest := NewCircuitBreakerExecutor(ImmediateExecutor{}, CircuitBreakerOptions{
+		FailureThreshold: 1,
+		OpenTimeout:      20 * time.Millisecond,
+		Clock:            clock,
+	})
+	var failing TaskFunc = func(ctx context.Context) error {
+		panic("test panic")
+	}
+	_, err := failing.Executor(underTest).Execution().Await(context.Background())
+	_, ok := err.(PanicError)
+	assertTrue(t, ok)
+	assertEqual(t, CircuitOpen, underTest.State(""))
+	clock.Advance(20 * time.Millisecond)
+	_, err = failing.Executor(underTest).Execution().Await(context.Background())
+	_, ok = err.(PanicError)
+	assertTrue(t, ok)
+	assertEqual(t, CircuitOpen, underTest.State(""))
+}
+
+// rejectingExecutor reports the tasks as rejected without running them while it has rejections left.
+type rejectingExecutor struct {
+	rejections *int32
+}
+
+var errRejected = errors.New("rejected")
+
+func (r rejectingExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	if atomic.AddInt32(r.rejections, -1) >= 0 {
+		resultChn <- TaskResult{
+			err: errRejected,
+			id:  taskId,
+		}
+		return
+	}
+	ImmediateExecutor{}.Execute(ctx, taskFunc, taskId, resultChn, opt)
+}
+
+func TestCircuitBreakerExecutor_HalfOpenRejectedTrial(t *testing.T) {
+	clock := newFakeClock()
+	var rejections int32
+	underTest := NewCircuitBreakerExecutor(rejectingExecutor{rejections: &rejections}, CircuitBreakerOptions{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Second,
+		Clock:            clock,
+	})
+	var failing TaskFunc = func(ctx context.Context) error {
+		return errors.New("test")
+	}
+	var succeeding TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	_, err := failing.Executor(underTest).CircuitBreaker("db").Execution().Await(context.Background())
+	assertNotNil(t, err)
+	clock.Advance(time.Second)
+
+	// the trial is rejected before it runs, which neither counts nor keeps its slot
+	atomic.StoreInt32(&rejections, 1)
+	_, err = succeeding.Executor(underTest).CircuitBreaker("db").Execution().Await(context.Background())
+	assertEqual(t, errRejected, err)
+	assertEqual(t, CircuitHalfOpen, underTest.State("db"))
+	_, err = succeeding.Executor(underTest).CircuitBreaker("db").Execution().Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, CircuitClosed, underTest.State("db"))
+}
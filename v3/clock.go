@@ -0,0 +1,105 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the timers used by the library, so that tests can replace it with
+// a virtual clock instead of sleeping.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+type SystemClock struct {
+}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+type clockContextKey struct{}
+
+// WithClock returns a context making the timers of the tasks executed with it, such as hedging
+// delays, use the given clock.
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, clock)
+}
+
+// ClockFromContext returns the clock set by WithClock, or SystemClock if there is none.
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return clock
+	}
+	return SystemClock{}
+}
+
+// timeoutContext is a context cancelled by a timer of a Clock, which reports
+// context.DeadlineExceeded like the contexts of context.WithTimeout.
+type timeoutContext struct {
+	context.Context
+	deadline time.Time
+	mu       sync.Mutex
+	err      error
+}
+
+// withClockTimeout is like context.WithTimeout, with the deadline measured with the clock of ctx.
+func withClockTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	clock := ClockFromContext(ctx)
+	cancelCtx, cancel := context.WithCancel(ctx)
+	ret := &timeoutContext{
+		Context:  cancelCtx,
+		deadline: clock.Now().Add(d),
+	}
+	timer := clock.NewTimer(d)
+	go func() {
+		pprof.SetGoroutineLabels(timeoutLabels)
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			ret.mu.Lock()
+			ret.err = context.DeadlineExceeded
+			ret.mu.Unlock()
+			cancel()
+		case <-cancelCtx.Done():
+		}
+	}()
+	return ret, cancel
+}
+
+func (c *timeoutContext) Deadline() (time.Time, bool) {
+	if deadline, ok := c.Context.Deadline(); ok && deadline.Before(c.deadline) {
+		return deadline, true
+	}
+	return c.deadline, true
+}
+
+func (c *timeoutContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return c.Context.Err()
+}
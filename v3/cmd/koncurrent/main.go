@@ -0,0 +1,280 @@
+// Command koncurrent checks, draws and dry-runs workflow definition files.
+//
+//	koncurrent validate [-tasks auth,price] [-executors pool] checkout.yaml
+//	koncurrent graph [-format dot|mermaid] checkout.yaml
+//	koncurrent simulate [-seed 1] [-pool-size 4] checkout.yaml
+//
+// simulate runs the workflow with stub tasks that sleep for the duration and fail with the
+// probability set in the metadata of their node:
+//
+//	stages:
+//	  - parallel:
+//	      - task: stock
+//	        metadata: {duration: 40ms, failure: 0.1}
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/raymond852/koncurrent/v3"
+	"github.com/raymond852/koncurrent/v3/workflow"
+)
+
+const usage = `usage: koncurrent <command> [flags] <workflow file>
+
+commands:
+  validate  check the workflow file
+  graph     print the workflow as a Graphviz DOT or Mermaid graph
+  simulate  run the workflow with stub tasks and print its critical path and results
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "validate":
+		err = validate(args[1:], stdout, stderr)
+	case "graph":
+		err = graph(args[1:], stdout, stderr)
+	case "simulate":
+		err = simulate(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// load parses the flags of a command and loads the workflow file they are followed by.
+func load(flags *flag.FlagSet, args []string) (*workflow.Definition, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return nil, flag.ErrHelp
+	}
+	return workflow.Load(flags.Arg(0))
+}
+
+func names(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// stubRegistry registers the tasks and executors named in the definition, doing nothing.
+func stubRegistry(def *workflow.Definition) *workflow.Registry {
+	registry := workflow.NewRegistry()
+	for _, stage := range def.Stages {
+		for _, node := range stage.Nodes {
+			registry.RegisterTask(node.Task, nil)
+			registry.RegisterExecutor(def.ExecutorOf(node), koncurrent.ImmediateExecutor{})
+		}
+	}
+	return registry
+}
+
+func validate(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	tasks := flags.String("tasks", "", "comma separated names of the known tasks, any task is accepted if empty")
+	executors := flags.String("executors", "", "comma separated names of the known executors besides immediate and async, any executor is accepted if empty")
+	def, err := load(flags, args)
+	if err != nil {
+		return err
+	}
+	registry := workflow.NewRegistry()
+	for _, stage := range def.Stages {
+		for _, node := range stage.Nodes {
+			if *tasks == "" {
+				registry.RegisterTask(node.Task, nil)
+			}
+			if *executors == "" {
+				registry.RegisterExecutor(def.ExecutorOf(node), nil)
+			}
+		}
+	}
+	for _, name := range names(*tasks) {
+		registry.RegisterTask(name, nil)
+	}
+	for _, name := range names(*executors) {
+		registry.RegisterExecutor(name, nil)
+	}
+	if err := def.Validate(registry); err != nil {
+		if list, ok := err.(workflow.ErrorList); ok {
+			for i := range list {
+				list[i].File = flags.Arg(0)
+			}
+		}
+		return err
+	}
+	fmt.Fprintf(stdout, "%s: ok\n", flags.Arg(0))
+	return nil
+}
+
+func graph(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("graph", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "dot", "graph format, dot or mermaid")
+	def, err := load(flags, args)
+	if err != nil {
+		return err
+	}
+	execution, err := def.Build(stubRegistry(def))
+	if err != nil {
+		return err
+	}
+	plan := execution.Describe()
+	// the executors are stubs, show the names they have in the file instead
+	for i, stage := range def.Stages {
+		for j, node := range stage.Nodes {
+			plan.Stages[i].Tasks[j].Executor = def.ExecutorOf(node)
+		}
+	}
+	switch *format {
+	case "dot":
+		fmt.Fprint(stdout, plan.DOT())
+	case "mermaid":
+		fmt.Fprint(stdout, plan.Mermaid())
+	default:
+		return fmt.Errorf("unknown graph format %q", *format)
+	}
+	return nil
+}
+
+var errSimulated = errors.New("simulated failure")
+
+// stubTask sleeps for the duration of the node and fails with its failure probability, drawn from a
+// source of its own so that the outcomes for a seed do not depend on the interleaving of the tasks.
+func stubTask(node workflow.Node, seed int64) (koncurrent.TaskFunc, error) {
+	var duration time.Duration
+	var failure float64
+	var err error
+	if s, ok := node.Metadata["duration"]; ok {
+		if duration, err = time.ParseDuration(s); err != nil {
+			return nil, fmt.Errorf("%d:%d: invalid duration of task %q: %s", node.Line, node.Column, node.Name, err)
+		}
+	}
+	if s, ok := node.Metadata["failure"]; ok {
+		if failure, err = strconv.ParseFloat(s, 64); err != nil || failure < 0 || failure > 1 {
+			return nil, fmt.Errorf("%d:%d: invalid failure probability of task %q: %q", node.Line, node.Column, node.Name, s)
+		}
+	}
+	var mu sync.Mutex
+	rnd := rand.New(rand.NewSource(seed))
+	return func(ctx context.Context) error {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		mu.Lock()
+		failed := rnd.Float64() < failure
+		mu.Unlock()
+		if failed {
+			return errSimulated
+		}
+		return nil
+	}, nil
+}
+
+func simulate(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	seed := flags.Int64("seed", 1, "seed of the simulated failures")
+	poolSize := flags.Int("pool-size", 4, "number of workers of the pools standing in for the executors named in the file")
+	def, err := load(flags, args)
+	if err != nil {
+		return err
+	}
+	registry := workflow.NewRegistry()
+	pools := map[string]koncurrent.PoolExecutor{}
+	addPool := func(name string) {
+		if _, ok := pools[name]; ok || name == "" || name == "immediate" || name == "async" {
+			return
+		}
+		pools[name] = koncurrent.NewPoolExecutor(*poolSize, *poolSize).Name(name)
+		registry.RegisterExecutor(name, pools[name])
+	}
+	defer func() {
+		for _, pool := range pools {
+			pool.Close()
+		}
+	}()
+	addPool(def.Executor)
+	index := int64(0)
+	for i := range def.Stages {
+		for j := range def.Stages[i].Nodes {
+			node := &def.Stages[i].Nodes[j]
+			addPool(node.Executor)
+			// every node gets a stub of its own, even when nodes share a task
+			task, err := stubTask(*node, *seed+index)
+			if err != nil {
+				return err
+			}
+			index++
+			node.Task = node.Name
+			registry.RegisterTask(node.Task, task)
+		}
+	}
+	execution, err := def.Build(registry)
+	if err != nil {
+		return err
+	}
+	recorder := koncurrent.NewRecorder()
+	start := time.Now()
+	results, err := execution.Observe(recorder).Await(context.Background())
+	total := time.Since(start)
+
+	report := recorder.Analyze()
+	path := make([]string, len(report.CriticalPath))
+	for i, task := range report.CriticalPath {
+		path[i] = fmt.Sprintf("%s (%s)", task.Name, task.Total().Round(time.Millisecond))
+	}
+	fmt.Fprintf(stdout, "critical path: %s\n", strings.Join(path, " -> "))
+	fmt.Fprintf(stdout, "total time: %s\n", total.Round(time.Millisecond))
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tTASK\tDURATION\tRESULT")
+	for _, record := range recorder.Records() {
+		result := "ok"
+		if record.Err != nil {
+			result = record.Err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", record.Stage, record.Name, record.Finished.Sub(record.Started).Round(time.Millisecond), result)
+	}
+	w.Flush()
+	if err != nil {
+		fmt.Fprintf(stdout, "execution failed: %s\n", err)
+	}
+	fmt.Fprintf(stdout, "results: %v\n", results)
+	return nil
+}
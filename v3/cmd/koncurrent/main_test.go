@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const checkout = `name: checkout
+executor: pool
+stages:
+  - serial:
+      - task: auth
+        metadata: {duration: 5ms}
+  - parallel:
+      - task: price
+        metadata: {duration: 10ms}
+      - task: stock
+        metadata: {duration: 30ms}
+      - task: reviews
+        executor: async
+        metadata: {failure: 1}
+  - serial: [render]
+`
+
+func writeWorkflow(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "koncurrent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "checkout.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRun_Validate(t *testing.T) {
+	path, cleanup := writeWorkflow(t, checkout)
+	defer cleanup()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"validate", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	stdout.Reset()
+	code := run([]string{"validate", "-tasks", "auth,price,stock,render", "-executors", "pool", path}, &stdout, &stderr)
+	if code != 1 || stderr.String() != path+":12:9: unknown task \"reviews\"\n" {
+		t.Errorf("unexpected exit code %d and errors %q", code, stderr.String())
+	}
+}
+
+func TestRun_Graph(t *testing.T) {
+	path, cleanup := writeWorkflow(t, checkout)
+	defer cleanup()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"graph", "-format", "mermaid", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	for _, s := range []string{"flowchart LR", `s1t2["reviews<br/>async"]`, "s1t1 --> s2t0"} {
+		if !strings.Contains(stdout.String(), s) {
+			t.Errorf("missing %q in %s", s, stdout.String())
+		}
+	}
+}
+
+func TestRun_Simulate(t *testing.T) {
+	path, cleanup := writeWorkflow(t, checkout)
+	defer cleanup()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"simulate", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, s := range []string{"critical path: auth (", ") -> stock (", "total time: ", "simulated failure", "execution failed: simulated failure"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q in %s", s, out)
+		}
+	}
+	if strings.Contains(out, "render") {
+		t.Errorf("unexpected run of render in %s", out)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("unexpected exit code %d", code)
+	}
+	if code := run([]string{"draw"}, &stdout, &stderr); code != 2 {
+		t.Errorf("unexpected exit code %d", code)
+	}
+}
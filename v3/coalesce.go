@@ -0,0 +1,140 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// CoalescingExecutor runs at most one task at a time per coalesce key. Tasks submitted with the key
+// of a call in flight wait for that call and share its outcome instead of running. A waiter whose
+// context is done detaches with the context error, and the shared call is cancelled once all of its
+// waiters have detached. Tasks without a coalesce key run on the underlying executor as usual.
+type CoalescingExecutor struct {
+	executor TaskExecutor
+	group    coalesceGroup
+}
+
+type coalesceGroup struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescing is implemented by the caches and dedup stores that keep the group coalescing the tasks
+// run with their keys, so that the group goes away with them.
+type coalescing interface {
+	coalescer() *coalesceGroup
+}
+
+type coalescedCall struct {
+	done    chan struct{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func NewCoalescingExecutor(executor TaskExecutor) *CoalescingExecutor {
+	return &CoalescingExecutor{
+		executor: executor,
+	}
+}
+
+func (c *CoalescingExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	if len(opt.coalesceKey) == 0 {
+		c.executor.Execute(ctx, taskFunc, taskId, resultChn, opt)
+		return
+	}
+	c.group.execute(ctx, opt.coalesceKey, c.executor, taskFunc, taskId, resultChn, opt)
+}
+
+func (g *coalesceGroup) execute(ctx context.Context, key string, executor TaskExecutor, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	call := g.join(ctx, key, func(callCtx context.Context) error {
+		callResultChn := make(chan TaskResult, 1)
+		executor.Execute(callCtx, taskFunc, 0, callResultChn, opt)
+		return (<-callResultChn).err
+	})
+	go func() {
+		pprof.SetGoroutineLabels(coalesceLabels)
+		select {
+		case <-call.done:
+			resultChn <- TaskResult{
+				err: call.err,
+				id:  taskId,
+			}
+		case <-ctx.Done():
+			g.leave(key, call)
+			resultChn <- TaskResult{
+				err: ctx.Err(),
+				id:  taskId,
+			}
+		}
+	}()
+}
+
+func (g *coalesceGroup) join(ctx context.Context, key string, run func(ctx context.Context) error) *coalescedCall {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if call, ok := g.calls[key]; ok {
+		call.waiters++
+		return call
+	}
+	if g.calls == nil {
+		g.calls = make(map[string]*coalescedCall)
+	}
+	callCtx, cancel := context.WithCancel(detachedContext{ctx})
+	call := &coalescedCall{
+		done:    make(chan struct{}),
+		waiters: 1,
+		cancel:  cancel,
+	}
+	g.calls[key] = call
+	go func() {
+		pprof.SetGoroutineLabels(coalesceLabels)
+		err := run(callCtx)
+		g.mu.Lock()
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+		call.err = err
+		g.mu.Unlock()
+		cancel()
+		close(call.done)
+	}()
+	return call
+}
+
+func (g *coalesceGroup) leave(key string, call *coalescedCall) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	call.cancel()
+}
+
+// detachedContext keeps the values of its parent but not its deadline and cancellation, so that a
+// shared call outlives the waiter that started it.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,82 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCoalescingExecutor_Execute(t *testing.T) {
+	underTest := NewCoalescingExecutor(AsyncExecutor{})
+	var calls int32
+	release := make(chan struct{})
+	testErr := errors.New("test")
+	var lookup TaskFunc = func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return testErr
+	}
+	resultChn := make(chan TaskResult, 5)
+	for i := 0; i < 5; i++ {
+		underTest.Execute(context.Background(), lookup, i, resultChn, TaskExecutionOptions{coalesceKey: "user:1"})
+	}
+	close(release)
+	for i := 0; i < 5; i++ {
+		assertEqual(t, testErr, (<-resultChn).err)
+	}
+	assertEqual(t, int32(1), atomic.LoadInt32(&calls))
+
+	// the call is forgotten once it completed
+	_, err := ExecuteParallel(lookup.Executor(underTest).CoalesceKey("user:1")).Await(context.Background())
+	assertEqual(t, testErr, err)
+	assertEqual(t, int32(2), atomic.LoadInt32(&calls))
+	_, err = ExecuteParallel(lookup.Executor(underTest), lookup.Executor(underTest)).Await(context.Background())
+	assertNotNil(t, err)
+	assertEqual(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestCoalescingExecutor_Detach(t *testing.T) {
+	underTest := NewCoalescingExecutor(AsyncExecutor{})
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	var lookup TaskFunc = func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	resultChn := make(chan TaskResult, 2)
+	opts := TaskExecutionOptions{coalesceKey: "user:1"}
+	underTest.Execute(ctx1, lookup, 0, resultChn, opts)
+	underTest.Execute(ctx2, lookup, 1, resultChn, opts)
+	<-started
+
+	cancel1()
+	result := <-resultChn
+	assertEqual(t, 0, result.id)
+	assertEqual(t, context.Canceled, result.err)
+	select {
+	case <-cancelled:
+		t.Error("shared call cancelled while a waiter remains")
+	default:
+	}
+
+	cancel2()
+	result = <-resultChn
+	assertEqual(t, 1, result.id)
+	<-cancelled
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	var ok TaskFunc = func(ctx context.Context) error {
+		wg.Done()
+		return nil
+	}
+	_, err := ExecuteSerial(ok.Executor(underTest).CoalesceKey("user:1")).Await(context.Background())
+	assertNil(t, err)
+	wg.Wait()
+}
@@ -0,0 +1,377 @@
+package koncurrent
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression.
+type CronSchedule struct {
+	second   uint64
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	domStar  bool
+	dowStar  bool
+	location *time.Location
+}
+
+type cronField struct {
+	name  string
+	min   uint
+	max   uint
+	names map[string]uint
+}
+
+var (
+	cronSecond = cronField{name: "second", min: 0, max: 59}
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// both 0 and 7 are sunday, 7 is folded into 0 once the field is expanded
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard 5 field cron expression (minute hour day-of-month month day-of-week) or
+// a 6 field one with a leading second field. The expression may be prefixed with CRON_TZ=<zone> or
+// TZ=<zone> to evaluate it in that time zone, and the @yearly, @monthly, @weekly, @daily and
+// @hourly descriptors are accepted.
+func ParseCron(expr string) (CronSchedule, error) {
+	var schedule CronSchedule
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.IndexByte(spec, ' ')
+		if i < 0 {
+			return schedule, fmt.Errorf("cron %q: missing fields after time zone", expr)
+		}
+		zone := spec[strings.IndexByte(spec, '=')+1 : i]
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return schedule, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		schedule.location = loc
+		spec = strings.TrimSpace(spec[i:])
+	}
+	if descriptor, ok := cronDescriptors[spec]; ok {
+		spec = descriptor
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return schedule, fmt.Errorf("cron %q: expected 5 or 6 fields, found %d", expr, len(fields))
+	}
+	var err error
+	targets := []*uint64{&schedule.second, &schedule.minute, &schedule.hour, &schedule.dom, &schedule.month, &schedule.dow}
+	for i, field := range []cronField{cronSecond, cronMinute, cronHour, cronDom, cronMonth, cronDow} {
+		if *targets[i], err = field.parse(fields[i]); err != nil {
+			return schedule, fmt.Errorf("cron %q: %w", expr, err)
+		}
+	}
+	// like in Vixie cron, a day field starting with a wildcard, such as */2, restricts the days
+	// along with the other day field rather than adding to it
+	schedule.domStar = strings.HasPrefix(fields[3], "*") || strings.HasPrefix(fields[3], "?")
+	schedule.dowStar = strings.HasPrefix(fields[5], "*") || strings.HasPrefix(fields[5], "?")
+	return schedule, nil
+}
+
+func (f cronField) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		step := uint(1)
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", part[i+1:], f.name)
+			}
+			step = uint(n)
+			part = part[:i]
+		}
+		var low, high uint
+		switch {
+		case part == "*" || part == "?":
+			low, high = f.min, f.max
+		case strings.IndexByte(part, '-') > 0:
+			i := strings.IndexByte(part, '-')
+			var err error
+			if low, err = f.value(part[:i]); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(part[i+1:]); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if low, err = f.value(part); err != nil {
+				return 0, err
+			}
+			high = low
+			if step > 1 {
+				high = f.max
+			}
+		}
+		if low > high {
+			return 0, fmt.Errorf("invalid range %q in %s field", part, f.name)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	if f.name == cronDow.name && bits&(1<<7) != 0 {
+		bits = bits&^(1<<7) | 1
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (uint, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < int(f.min) || n > int(f.max) {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+	return uint(n), nil
+}
+
+// Next returns the first time after t matching the schedule, or the zero time if there is none in
+// the next five years.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	loc := s.location
+	if loc == nil {
+		loc = origLoc
+	}
+	t = t.In(loc).Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for 1<<uint(t.Month())&s.month == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+	for 1<<uint(t.Second())&s.second == 0 {
+		t = t.Truncate(time.Second).Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+	return t.In(origLoc)
+}
+
+func (s CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom > 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow > 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+type OverlapPolicy int
+
+const (
+	// OverlapSkip drops a tick while the previous run is still in progress.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs the ticks that happen during a run one after another once it finishes.
+	OverlapQueue
+	// OverlapAllow starts a run on every tick even if previous runs are still in progress.
+	OverlapAllow
+)
+
+type CronOptions struct {
+	Overlap OverlapPolicy
+	// Missed is applied to the ticks the clock jumped past, for example while the machine was
+	// suspended. MissedRunCoalesce runs once, scheduled at the first missed tick, and MissedRunSkip
+	// only runs a tick due at the time the clock jumped to. The runs are subject to the overlap
+	// policy. Defaults to MissedRunCoalesce.
+	Missed MissedRunPolicy
+	// History is the number of latest runs kept by the job. Defaults to 10.
+	History int
+}
+
+type CronRun struct {
+	Scheduled time.Time
+	Started   time.Time
+	Finished  time.Time
+	Skipped   bool
+	Results   ExecutionResults
+	Err       error
+}
+
+type CronJob struct {
+	scheduler *Scheduler
+	schedule  CronSchedule
+	execution Execution
+	options   CronOptions
+	cancel    context.CancelFunc
+	done      chan struct{}
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	running   int
+	queued    []time.Time
+	history   []CronRun
+}
+
+// ScheduleCron awaits the execution on every tick of the cron expression until the job is stopped.
+func (s *Scheduler) ScheduleCron(expr string, execution Execution, options CronOptions) (*CronJob, error) {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	if options.History <= 0 {
+		options.History = 10
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &CronJob{
+		scheduler: s,
+		schedule:  schedule,
+		execution: execution,
+		options:   options,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	go job.loop(ctx)
+	return job, nil
+}
+
+func (j *CronJob) loop(ctx context.Context) {
+	pprof.SetGoroutineLabels(schedulerLabels)
+	defer close(j.done)
+	next := j.schedule.Next(j.scheduler.clock.Now())
+	for !next.IsZero() && j.scheduler.waitUntil(ctx, next) {
+		following := j.schedule.Next(next)
+		now := j.scheduler.clock.Now()
+		if j.options.Missed == MissedRunCatchUp || following.IsZero() || following.After(now) {
+			j.tick(ctx, next)
+			next = following
+			continue
+		}
+		// the clock jumped past the ticks after next
+		switch j.options.Missed {
+		case MissedRunSkip:
+			// Next of the second before now is now if a tick is due now
+			if due := j.schedule.Next(now.Add(-time.Second)); !due.After(now) {
+				j.tick(ctx, due)
+			}
+		default:
+			j.tick(ctx, next)
+		}
+		next = j.schedule.Next(now)
+	}
+	j.wg.Wait()
+}
+
+func (j *CronJob) tick(ctx context.Context, scheduled time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.running > 0 {
+		switch j.options.Overlap {
+		case OverlapSkip:
+			j.record(CronRun{Scheduled: scheduled, Skipped: true})
+			return
+		case OverlapQueue:
+			j.queued = append(j.queued, scheduled)
+			return
+		}
+	}
+	j.running++
+	j.wg.Add(1)
+	go j.run(ctx, scheduled)
+}
+
+func (j *CronJob) run(ctx context.Context, scheduled time.Time) {
+	pprof.SetGoroutineLabels(schedulerLabels)
+	defer j.wg.Done()
+	for {
+		run := CronRun{
+			Scheduled: scheduled,
+			Started:   j.scheduler.clock.Now(),
+		}
+		run.Results, run.Err = j.execution.Await(ctx)
+		run.Finished = j.scheduler.clock.Now()
+		j.mu.Lock()
+		j.record(run)
+		if len(j.queued) == 0 || ctx.Err() != nil {
+			j.running--
+			j.mu.Unlock()
+			return
+		}
+		scheduled = j.queued[0]
+		j.queued = j.queued[1:]
+		j.mu.Unlock()
+	}
+}
+
+func (j *CronJob) record(run CronRun) {
+	if len(j.history) == j.options.History {
+		copy(j.history, j.history[1:])
+		j.history = j.history[:len(j.history)-1]
+	}
+	j.history = append(j.history, run)
+}
+
+// History returns the latest runs, oldest first.
+func (j *CronJob) History() []CronRun {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]CronRun(nil), j.history...)
+}
+
+// Stop stops scheduling new runs and cancels the context of the runs in progress.
+func (j *CronJob) Stop() {
+	j.cancel()
+}
+
+// Done is closed once the job is stopped and its runs in progress have returned.
+func (j *CronJob) Done() <-chan struct{} {
+	return j.done
+}
@@ -0,0 +1,127 @@
+package koncurrent
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	from := time.Date(2021, 3, 15, 10, 30, 15, 0, time.UTC)
+	testCases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2021, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/20 * * * * *", time.Date(2021, 3, 15, 10, 30, 20, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2021, 3, 16, 2, 0, 0, 0, time.UTC)},
+		{"15,45 9-17 * * MON-FRI", time.Date(2021, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 7", time.Date(2021, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 5-7", time.Date(2021, 3, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0-7/7", time.Date(2021, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */2 * MON", time.Date(2021, 3, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * */3", time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=Asia/Tokyo 0 9 * * *", time.Date(2021, 3, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, testCase := range testCases {
+		schedule, err := ParseCron(testCase.expr)
+		assertNil(t, err)
+		next := schedule.Next(from)
+		if !next.Equal(testCase.expected) {
+			t.Errorf("%s: expected %s, got %s", testCase.expr, testCase.expected, next)
+		}
+	}
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * mon-sun/0", "5-1 * * * *", "TZ=Nowhere/Atlantis * * * * *"} {
+		_, err := ParseCron(expr)
+		assertNotNil(t, err)
+	}
+}
+
+func TestScheduler_ScheduleCron(t *testing.T) {
+	for _, policy := range []OverlapPolicy{OverlapSkip, OverlapQueue} {
+		clock := newFakeClock()
+		underTest := NewScheduler(nil, clock)
+		started := make(chan struct{}, 3)
+		release := make(chan struct{})
+		var task TaskFunc = func(ctx context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}
+		job, err := underTest.ScheduleCron("* * * * * *", task.Immediate().Execution(), CronOptions{Overlap: policy, History: 2})
+		assertNil(t, err)
+		clock.blockUntil(1)
+		clock.Advance(time.Second)
+		<-started
+		clock.blockUntil(1)
+		clock.Advance(time.Second)
+		clock.blockUntil(1)
+		release <- struct{}{}
+		if policy == OverlapQueue {
+			<-started
+			release <- struct{}{}
+		}
+		for len(job.History()) < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		job.Stop()
+		<-job.Done()
+		history := job.History()
+		assertEqual(t, 2, len(history))
+		if policy == OverlapSkip {
+			assertTrue(t, history[0].Skipped)
+			assertTrue(t, history[0].Scheduled.Equal(clock.Now()))
+			assertTrue(t, !history[1].Skipped)
+		} else {
+			assertTrue(t, !history[0].Skipped)
+			assertTrue(t, !history[1].Skipped)
+			assertTrue(t, history[1].Scheduled.Equal(clock.Now()))
+		}
+		assertNil(t, history[1].Err)
+		assertEqual(t, 1, len(history[1].Results))
+	}
+	_, err := NewScheduler(nil, nil).ScheduleCron("bad", Execution{}, CronOptions{})
+	assertNotNil(t, err)
+}
+
+func TestScheduler_ScheduleCronClockJump(t *testing.T) {
+	testCases := []struct {
+		policy    MissedRunPolicy
+		scheduled []time.Time
+	}{
+		{MissedRunCoalesce, []time.Time{time.Date(2021, 1, 1, 0, 1, 0, 0, time.UTC)}},
+		{MissedRunSkip, []time.Time{time.Date(2021, 1, 1, 0, 4, 0, 0, time.UTC)}},
+		{MissedRunCatchUp, []time.Time{
+			time.Date(2021, 1, 1, 0, 1, 0, 0, time.UTC),
+			time.Date(2021, 1, 1, 0, 2, 0, 0, time.UTC),
+			time.Date(2021, 1, 1, 0, 3, 0, 0, time.UTC),
+			time.Date(2021, 1, 1, 0, 4, 0, 0, time.UTC),
+		}},
+	}
+	for _, testCase := range testCases {
+		clock := newFakeClock()
+		underTest := NewScheduler(nil, clock)
+		var task TaskFunc = func(ctx context.Context) error {
+			return nil
+		}
+		job, err := underTest.ScheduleCron("0 * * * * *", task.Immediate().Execution(), CronOptions{Overlap: OverlapAllow, Missed: testCase.policy})
+		assertNil(t, err)
+		clock.blockUntil(1)
+		clock.Advance(4 * time.Minute)
+		// the job waits for the tick after the jump
+		clock.blockUntil(1)
+		job.Stop()
+		<-job.Done()
+		history := job.History()
+		sort.Slice(history, func(i, j int) bool {
+			return history[i].Scheduled.Before(history[j].Scheduled)
+		})
+		assertEqual(t, len(testCase.scheduled), len(history))
+		for i, scheduled := range testCase.scheduled {
+			assertTrue(t, history[i].Scheduled.Equal(scheduled))
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type TaskOutcome int
+
+const (
+	// OutcomeUnknown is the outcome of the tasks of a plan that is not matched with results, and of
+	// the tasks of the stage cut short by the context whose outcome Await did not receive.
+	OutcomeUnknown TaskOutcome = iota
+	OutcomeSucceeded
+	OutcomeFailed
+	// OutcomeNotRun is the outcome of the tasks after the stage or serial task that failed, and of
+	// the stages not reached because the context was done.
+	OutcomeNotRun
+)
+
+func (o TaskOutcome) String() string {
+	switch o {
+	case OutcomeSucceeded:
+		return "succeeded"
+	case OutcomeFailed:
+		return "failed"
+	case OutcomeNotRun:
+		return "not run"
+	default:
+		return "unknown"
+	}
+}
+
+// Plan describes the stages of an execution.
+type Plan struct {
+	Name   string
+	Stages []PlanStage
+}
+
+type PlanStage struct {
+	Parallel bool
+	Tasks    []PlanTask
+}
+
+type PlanTask struct {
+	// Name is the name of the task, or its position in the execution if it has none.
+	Name string
+	// Executor is the kind of executor running the task, with the executors it decorates in
+	// parentheses, for example "hedge(circuit-breaker(pool))".
+	Executor string
+	// Pool is the name of the PoolExecutor running the task, if it has one.
+	Pool    string
+	Outcome TaskOutcome
+	Err     error
+}
+
+// Describe returns the plan of the execution, its stages and their tasks.
+func (e Execution) Describe() Plan {
+	plan := Plan{
+		Name:   e.name,
+		Stages: make([]PlanStage, len(e.tasksList)),
+	}
+	for i, tasks := range e.tasksList {
+		stage := PlanStage{
+			Parallel: e.executionTypeList[i] == executionTypeParallel,
+			Tasks:    make([]PlanTask, len(tasks)),
+		}
+		for j, task := range tasks {
+			stage.Tasks[j] = PlanTask{
+				Name:     taskName(i, j, task.options.name),
+				Executor: executorKind(task.executor),
+			}
+			if pe, ok := task.executor.(PoolExecutor); ok {
+				stage.Tasks[j].Pool = pe.name
+			}
+		}
+		plan.Stages[i] = stage
+	}
+	return plan
+}
+
+// WithResults returns a copy of the plan with the outcome of every task taken from the results and
+// the error of an Await of its execution. When the context of the Await was done, the tasks of the
+// stage it cut short whose outcome it did not receive may have succeeded, still be running or not
+// have started, so their outcome is unknown. The stage is told from the results and error, which
+// cannot tell a last stage cut short from a successful one when the execution has no compensation
+// and Await returned a nil error: WithRecords can.
+func (p Plan) WithResults(results ExecutionResults, err error) Plan {
+	ret := p
+	ret.Stages = make([]PlanStage, len(p.Stages))
+	// Await leaves the results of the stages it did not reach nil
+	reached := 0
+	for reached < len(results) && reached < len(p.Stages) && results[reached] != nil {
+		reached++
+	}
+	// a nil error with stages not reached is an Await of an execution without compensations that
+	// was cut short
+	cancelled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		err == nil && reached < len(p.Stages)
+	for i, stage := range p.Stages {
+		stage.Tasks = append([]PlanTask(nil), stage.Tasks...)
+		var errs []error
+		if i < reached {
+			errs = results[i]
+		}
+		// the last stage reached was cut short unless it failed by itself, which a serial stage only
+		// does with the error of a task
+		cutShort := cancelled && i == reached-1
+		if cutShort && !stage.Parallel {
+			for _, taskErr := range errs {
+				if taskErr != nil {
+					cutShort = false
+				}
+			}
+		}
+		failed := false
+		for j := range stage.Tasks {
+			switch {
+			case errs == nil || j >= len(errs) || failed:
+				stage.Tasks[j].Outcome = OutcomeNotRun
+			case errs[j] != nil:
+				stage.Tasks[j].Outcome = OutcomeFailed
+				stage.Tasks[j].Err = errs[j]
+				failed = !stage.Parallel
+			case cutShort:
+				stage.Tasks[j].Outcome = OutcomeUnknown
+			default:
+				stage.Tasks[j].Outcome = OutcomeSucceeded
+			}
+		}
+		ret.Stages[i] = stage
+	}
+	return ret
+}
+
+// WithRecords returns a copy of the plan with the outcome of every task taken from the recorder that
+// observed an Await of its execution. The tasks whose outcome Await did not receive because its
+// context was done are unknown if they were handed to their executor, and not run otherwise, as are
+// the tasks skipped from a checkpoint.
+func (p Plan) WithRecords(recorder *Recorder) Plan {
+	records := make(map[[2]int]TaskRecord)
+	for _, record := range recorder.Records() {
+		records[[2]int{record.Stage, record.Task}] = record
+	}
+	ret := p
+	ret.Stages = make([]PlanStage, len(p.Stages))
+	for i, stage := range p.Stages {
+		stage.Tasks = append([]PlanTask(nil), stage.Tasks...)
+		for j := range stage.Tasks {
+			record, ok := records[[2]int{i, j}]
+			switch {
+			case !ok || record.Submitted.IsZero():
+				stage.Tasks[j].Outcome = OutcomeNotRun
+			case record.Finished.IsZero():
+				stage.Tasks[j].Outcome = OutcomeUnknown
+			case record.Err != nil:
+				stage.Tasks[j].Outcome = OutcomeFailed
+				stage.Tasks[j].Err = record.Err
+			default:
+				stage.Tasks[j].Outcome = OutcomeSucceeded
+			}
+		}
+		ret.Stages[i] = stage
+	}
+	return ret
+}
+
+func executorKind(executor TaskExecutor) string {
+	switch e := executor.(type) {
+	case ImmediateExecutor:
+		return "immediate"
+	case AsyncExecutor:
+		return "async"
+	case PoolExecutor:
+		return "pool"
+	case *WorkStealingPoolExecutor:
+		return "work-stealing"
+	case hedgeExecutor:
+		return "hedge(" + executorKind(e.executor) + ")"
+	case cacheExecutor:
+		return "cache(" + executorKind(e.executor) + ")"
+	case *CircuitBreakerExecutor:
+		return "circuit-breaker(" + executorKind(e.executor) + ")"
+	case *Bulkhead:
+		return "bulkhead(" + executorKind(e.group.executor) + ")"
+	case *CoalescingExecutor:
+		return "coalescing(" + executorKind(e.executor) + ")"
+	case batchItemExecutor:
+		return "batch(" + executorKind(e.batching.executor) + ")"
+	case *FaultInjectingExecutor:
+		return "fault(" + executorKind(e.executor) + ")"
+	case dedupExecutor:
+		return "dedup(" + executorKind(e.executor) + ")"
+	case LoggingExecutor:
+		return "logging(" + executorKind(e.executor) + ")"
+	default:
+		return fmt.Sprintf("%T", executor)
+	}
+}
+
+func (t PlanTask) label() string {
+	label := t.Name + "\n" + t.Executor
+	if t.Pool != "" {
+		label += " " + t.Pool
+	}
+	if t.Err != nil {
+		label += "\n" + t.Err.Error()
+	}
+	return label
+}
+
+var outcomeColors = map[TaskOutcome]string{
+	OutcomeSucceeded: "#b7e1a1",
+	OutcomeFailed:    "#f4a6a6",
+	OutcomeNotRun:    "#d9d9d9",
+}
+
+// edges calls edge for every pair of tasks where the second one starts after the first one: the
+// tasks of a serial stage one after another, and the last tasks of a stage before the first tasks
+// of the next one.
+func (p Plan) edges(edge func(from, to string)) {
+	var exits []string
+	for i, stage := range p.Stages {
+		if len(stage.Tasks) == 0 {
+			continue
+		}
+		var entries, stageExits []string
+		for j := range stage.Tasks {
+			id := fmt.Sprintf("s%dt%d", i, j)
+			if stage.Parallel || j == 0 {
+				entries = append(entries, id)
+			}
+			if stage.Parallel || j == len(stage.Tasks)-1 {
+				stageExits = append(stageExits, id)
+			}
+			if !stage.Parallel && j > 0 {
+				edge(fmt.Sprintf("s%dt%d", i, j-1), id)
+			}
+		}
+		for _, from := range exits {
+			for _, to := range entries {
+				edge(from, to)
+			}
+		}
+		exits = stageExits
+	}
+}
+
+func stageLabel(i int, stage PlanStage) string {
+	if stage.Parallel {
+		return fmt.Sprintf("stage %d parallel", i)
+	}
+	return fmt.Sprintf("stage %d serial", i)
+}
+
+// DOT renders the plan as a Graphviz digraph with a cluster per stage. The tasks of a plan with
+// results are filled with the colour of their outcome.
+func (p Plan) DOT() string {
+	var sb strings.Builder
+	name := p.Name
+	if name == "" {
+		name = "execution"
+	}
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	fmt.Fprintf(&sb, "digraph \"%s\" {\n\trankdir=LR;\n\tnode [shape=box];\n", quote.Replace(name))
+	for i, stage := range p.Stages {
+		fmt.Fprintf(&sb, "\tsubgraph cluster_%d {\n\t\tlabel=\"%s\";\n", i, stageLabel(i, stage))
+		for j, task := range stage.Tasks {
+			fmt.Fprintf(&sb, "\t\ts%dt%d [label=\"%s\"", i, j, quote.Replace(task.label()))
+			if color, ok := outcomeColors[task.Outcome]; ok {
+				fmt.Fprintf(&sb, ", style=filled, fillcolor=\"%s\"", color)
+			}
+			sb.WriteString("];\n")
+		}
+		sb.WriteString("\t}\n")
+	}
+	p.edges(func(from, to string) {
+		fmt.Fprintf(&sb, "\t%s -> %s;\n", from, to)
+	})
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid renders the plan as a Mermaid flowchart with a subgraph per stage. The tasks of a plan
+// with results get the class, and colour, of their outcome.
+func (p Plan) Mermaid() string {
+	var sb strings.Builder
+	// the characters Mermaid or the HTML of its labels give a meaning to are written as entity codes
+	quote := strings.NewReplacer(`"`, "#quot;", "#", "#35;", "&", "#amp;", "<", "#lt;", ">", "#gt;", "\n", "<br/>")
+	sb.WriteString("flowchart LR\n")
+	classes := map[TaskOutcome][]string{}
+	for i, stage := range p.Stages {
+		fmt.Fprintf(&sb, "  subgraph stage%d[\"%s\"]\n", i, stageLabel(i, stage))
+		for j, task := range stage.Tasks {
+			id := fmt.Sprintf("s%dt%d", i, j)
+			fmt.Fprintf(&sb, "    %s[\"%s\"]\n", id, quote.Replace(task.label()))
+			if _, ok := outcomeColors[task.Outcome]; ok {
+				classes[task.Outcome] = append(classes[task.Outcome], id)
+			}
+		}
+		sb.WriteString("  end\n")
+	}
+	p.edges(func(from, to string) {
+		fmt.Fprintf(&sb, "  %s --> %s\n", from, to)
+	})
+	for _, outcome := range []TaskOutcome{OutcomeSucceeded, OutcomeFailed, OutcomeNotRun} {
+		if ids := classes[outcome]; len(ids) > 0 {
+			class := strings.Replace(outcome.String(), " ", "", -1)
+			fmt.Fprintf(&sb, "  classDef %s fill:%s\n  class %s %s\n", class, outcomeColors[outcome], strings.Join(ids, ","), class)
+		}
+	}
+	return sb.String()
+}
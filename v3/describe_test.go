@@ -0,0 +1,108 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecution_Describe(t *testing.T) {
+	pe := NewPoolExecutor(2, 2)
+	defer pe.Close()
+	var succeeding TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var failing TaskFunc = func(ctx context.Context) error {
+		return errors.New("test")
+	}
+	execution := ExecuteParallel(succeeding.Pool(pe.Name("orders")).Name("price"), succeeding.Async().Hedge(time.Second, 2).Name("stock")).
+		ExecuteSerial(succeeding.Immediate().Name("reserve"), failing.Immediate().Name("charge"), succeeding.Immediate()).
+		ExecuteSerial(succeeding.Immediate().Name("render")).
+		Name("checkout")
+	plan := execution.Describe()
+	assertEqual(t, "checkout", plan.Name)
+	assertEqual(t, 3, len(plan.Stages))
+	assertTrue(t, plan.Stages[0].Parallel)
+	assertTrue(t, !plan.Stages[1].Parallel)
+	assertEqual(t, "price", plan.Stages[0].Tasks[0].Name)
+	assertEqual(t, "pool", plan.Stages[0].Tasks[0].Executor)
+	assertEqual(t, "orders", plan.Stages[0].Tasks[0].Pool)
+	assertEqual(t, "hedge(async)", plan.Stages[0].Tasks[1].Executor)
+	assertEqual(t, "stage1-task2", plan.Stages[1].Tasks[2].Name)
+	assertEqual(t, OutcomeUnknown, plan.Stages[1].Tasks[0].Outcome)
+
+	dot := plan.DOT()
+	for _, s := range []string{"digraph \"checkout\"", "subgraph cluster_0", "label=\"stage 1 serial\"", "s0t0 -> s1t0;", "s0t1 -> s1t0;", "s1t0 -> s1t1;", "s1t2 -> s2t0;"} {
+		assertTrue(t, strings.Contains(dot, s))
+	}
+	assertTrue(t, !strings.Contains(dot, "fillcolor"))
+
+	results, err := execution.Await(context.Background())
+	assertNotNil(t, err)
+	plan = plan.WithResults(results, err)
+	assertEqual(t, OutcomeSucceeded, plan.Stages[0].Tasks[1].Outcome)
+	assertEqual(t, OutcomeSucceeded, plan.Stages[1].Tasks[0].Outcome)
+	assertEqual(t, OutcomeFailed, plan.Stages[1].Tasks[1].Outcome)
+	assertEqual(t, err, plan.Stages[1].Tasks[1].Err)
+	assertEqual(t, OutcomeNotRun, plan.Stages[1].Tasks[2].Outcome)
+	assertEqual(t, OutcomeNotRun, plan.Stages[2].Tasks[0].Outcome)
+	assertTrue(t, strings.Contains(plan.DOT(), "fillcolor"))
+
+	mermaid := plan.Mermaid()
+	for _, s := range []string{"flowchart LR", "subgraph stage0[\"stage 0 parallel\"]", "s1t1[\"charge<br/>immediate<br/>test\"]", "s1t0 --> s1t1", "class s1t1 failed", "class s1t2,s2t0 notrun"} {
+		assertTrue(t, strings.Contains(mermaid, s))
+	}
+}
+
+func TestPlan_WithResultsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	var succeeding TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var blocking TaskFunc = func(ctx context.Context) error {
+		cancel()
+		<-release
+		return nil
+	}
+	execution := ExecuteSerial(succeeding.Immediate().Name("reserve"), blocking.Async().Name("charge"), succeeding.Immediate().Name("notify")).
+		ExecuteSerial(succeeding.Immediate().Name("render"))
+	recorder := NewRecorder()
+	results, err := execution.Observe(recorder).Await(ctx)
+	close(release)
+	plan := execution.Describe().WithResults(results, err)
+	assertEqual(t, OutcomeUnknown, plan.Stages[0].Tasks[0].Outcome)
+	assertEqual(t, OutcomeUnknown, plan.Stages[0].Tasks[1].Outcome)
+	assertEqual(t, OutcomeUnknown, plan.Stages[0].Tasks[2].Outcome)
+	assertEqual(t, OutcomeNotRun, plan.Stages[1].Tasks[0].Outcome)
+
+	// the recorder tells the tasks that succeeded and were not run
+	plan = execution.Describe().WithRecords(recorder)
+	assertEqual(t, OutcomeSucceeded, plan.Stages[0].Tasks[0].Outcome)
+	assertEqual(t, OutcomeUnknown, plan.Stages[0].Tasks[1].Outcome)
+	assertEqual(t, OutcomeNotRun, plan.Stages[0].Tasks[2].Outcome)
+	assertEqual(t, OutcomeNotRun, plan.Stages[1].Tasks[0].Outcome)
+
+	// a stage failing with the context error of a task is not cut short
+	var timingOut TaskFunc = func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}
+	execution = ExecuteSerial(succeeding.Immediate(), timingOut.Immediate(), succeeding.Immediate())
+	results, err = execution.Await(context.Background())
+	plan = execution.Describe().WithResults(results, err)
+	assertEqual(t, OutcomeSucceeded, plan.Stages[0].Tasks[0].Outcome)
+	assertEqual(t, OutcomeFailed, plan.Stages[0].Tasks[1].Outcome)
+	assertEqual(t, OutcomeNotRun, plan.Stages[0].Tasks[2].Outcome)
+}
+
+func TestPlan_MermaidEscape(t *testing.T) {
+	var failing TaskFunc = func(ctx context.Context) error {
+		return errors.New(`<b>"#1" & more</b>`)
+	}
+	execution := ExecuteSerial(failing.Immediate().Name("a<b>"))
+	results, err := execution.Await(context.Background())
+	mermaid := execution.Describe().WithResults(results, err).Mermaid()
+	assertTrue(t, strings.Contains(mermaid, `s0t0["a#lt;b#gt;<br/>immediate<br/>#lt;b#gt;#quot;#35;1#quot; #amp; more#lt;/b#gt;"]`))
+}
@@ -3,6 +3,10 @@ package koncurrent
 import (
 	"context"
 	"fmt"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -33,6 +37,11 @@ func (er ExecutionResults) FlattenErrors() []error {
 type Execution struct {
 	tasksList         [][]TaskExecution
 	executionTypeList []int
+	observer          Observer
+	name              string
+	profilerLabels    bool
+	logger            *taskLogger
+	checkpoint        *checkpoint
 }
 
 type CaseExecution struct {
@@ -41,10 +50,69 @@ type CaseExecution struct {
 }
 
 func (e Execution) nextExecution(tasks []TaskExecution, executionType int) Execution {
-	return Execution{
-		tasksList:         append(e.tasksList, tasks),
-		executionTypeList: append(e.executionTypeList, executionType),
+	ret := e
+	ret.tasksList = append(e.tasksList, tasks)
+	ret.executionTypeList = append(e.executionTypeList, executionType)
+	return ret
+}
+
+func (e Execution) execute(ctx context.Context, task TaskExecution, stage int, taskId int, resultChn chan TaskResult) {
+	opts := task.options
+	opts.stage = stage
+	opts.execution = e.name
+	opts.profilerLabels = e.profilerLabels
+	taskFunc := task.taskFunc
+	if trace.IsEnabled() {
+		taskFunc = tracedTaskFunc(taskFunc, taskId, opts)
+	}
+	if e.logger != nil {
+		opts.logger = e.logger
+		taskFunc = loggedTaskFunc(taskFunc, taskId, opts)
+	}
+	if e.observer == nil {
+		task.executor.Execute(ctx, taskFunc, taskId, resultChn, opts)
+		return
 	}
+	opts.observer = e.observer
+	opts.emit(EventTaskSubmit, taskId, 0, nil)
+	task.executor.Execute(ctx, observedTaskFunc(taskFunc, taskId, opts), taskId, resultChn, opts)
+}
+
+func (e Execution) observeStage(kind EventKind, stage int, err error) {
+	e.observer.Observe(Event{
+		Kind:     kind,
+		Stage:    stage,
+		Tasks:    len(e.tasksList[stage]),
+		Parallel: e.executionTypeList[stage] == executionTypeParallel,
+		Time:     time.Now(),
+		Err:      err,
+	})
+}
+
+func (e Execution) observeResult(stage int, task TaskExecution, taskResult TaskResult) {
+	if e.observer == nil {
+		return
+	}
+	opts := task.options
+	opts.stage = stage
+	opts.observer = e.observer
+	opts.emit(EventTaskResult, taskResult.id, 0, taskResult.err)
+}
+
+// Name names the execution in the profiler labels and reports of its tasks.
+func (e Execution) Name(name string) Execution {
+	ret := e
+	ret.name = name
+	return ret
+}
+
+// ProfilerLabels runs every task of the execution with pprof labels for the executor, pool,
+// execution, stage and task, so that CPU profiles and goroutine dumps attribute the work to them.
+// The labels are set by the pool, async and immediate executors.
+func (e Execution) ProfilerLabels() Execution {
+	ret := e
+	ret.profilerLabels = true
+	return ret
 }
 
 func (e Execution) ExecuteParallel(tasks ...TaskExecution) Execution {
@@ -66,6 +134,7 @@ func (e Execution) Switch(defaultExec Execution, cases ...CaseExecution) Executi
 
 func (e Execution) Async(ctx context.Context, callback func(ExecutionResults, error)) {
 	go func() {
+		pprof.SetGoroutineLabels(executionLabels)
 		result, err := e.Await(ctx)
 		if callback != nil {
 			callback(result, err)
@@ -73,66 +142,195 @@ func (e Execution) Async(ctx context.Context, callback func(ExecutionResults, er
 	}()
 }
 
+// Await runs the stages one after another and returns the errors of their tasks. When its context
+// is done before the execution finishes, Await stops waiting for the tasks and returns a nil error,
+// unless a task of the execution has a compensation: it then returns the context error, wrapped in
+// a SagaError if the tasks that succeeded were compensated. While a runtime/trace is being
+// recorded, the Await is a trace task, named after the execution, with a region for every stage and
+// for every task. Once PublishExecutionStats has been called, it is also counted in the
+// GlobalExecutionStats.
 func (e Execution) Await(ctx context.Context) (ExecutionResults, error) {
+	if atomic.LoadInt32(&executions.enabled) != 0 {
+		return executions.await(ctx, e)
+	}
+	return e.await(ctx)
+}
+
+func (e Execution) await(ctx context.Context) (ExecutionResults, error) {
+	if trace.IsEnabled() {
+		var traceTask *trace.Task
+		ctx, traceTask = trace.NewTask(ctx, e.traceTaskType())
+		defer traceTask.End()
+	}
+	run, err := e.newAwaitRun()
+	if err != nil {
+		return nil, err
+	}
 	var ret ExecutionResults = make([][]error, len(e.tasksList))
-	var err error
 	for i := range e.tasksList {
 		currTaskList := e.tasksList[i]
 		execErr := make([]error, len(currTaskList))
 		ret[i] = execErr
+		if e.observer != nil {
+			e.observeStage(EventStageStart, i, nil)
+		}
+		var err error
+		var cancelled bool
+		started := time.Now()
+		region := trace.StartRegion(ctx, e.traceStageRegionType(i))
 		switch e.executionTypeList[i] {
 		case executionTypeParallel:
-			resultsChn := make(chan TaskResult, len(currTaskList))
-			for j, task := range currTaskList {
-				taskFunc := task.taskFunc
-				executor := task.executor
-				opts := task.options
-				executor.Execute(ctx, taskFunc, j, resultsChn, opts)
+			err, cancelled = e.awaitParallel(ctx, i, currTaskList, execErr, run)
+		default:
+			err, cancelled = e.awaitSerial(ctx, i, currTaskList, execErr, run)
+		}
+		region.End()
+		if cancelled {
+			if e.observer != nil {
+				e.observeStage(EventStageFinish, i, ctx.Err())
 			}
-			for range currTaskList {
-				select {
-				case taskResult := <-resultsChn:
-					execErr[taskResult.id] = taskResult.err
-				case <-ctx.Done():
-					return ret, err
-				}
+			if e.logger != nil {
+				e.logAbort(ctx, i, started, ctx.Err())
 			}
-			close(resultsChn)
-			for j := range execErr {
-				if execErr[j] != nil {
-					if panicErr, ok := execErr[i].(PanicError); ok {
-						return ret, panicErr
-					} else if err == nil {
-						err = execErr[j]
-					} else {
-						err = fmt.Errorf("%s:%w", execErr[j], err)
-					}
-				}
+			if run != nil && run.saga != nil {
+				return ret, run.compensate(ctx, ctx.Err())
 			}
-		default:
-			resultsChn := make(chan TaskResult, 1)
-			for j, task := range currTaskList {
-				taskFunc := task.taskFunc
-				opts := task.options
-				task.executor.Execute(ctx, taskFunc, j, resultsChn, opts)
-				select {
-				case taskResult := <-resultsChn:
-					execErr[j] = taskResult.err
-				case <-ctx.Done():
-					return ret, err
-				}
-				if execErr[j] != nil {
-					close(resultsChn)
-					return ret, execErr[j]
-				}
+			return ret, nil
+		}
+		if e.observer != nil {
+			e.observeStage(EventStageFinish, i, err)
+		}
+		if err != nil {
+			if e.logger != nil {
+				e.logAbort(ctx, i, started, err)
 			}
-			close(resultsChn)
+			return ret, run.compensate(ctx, err)
 		}
+	}
+	return ret, nil
+}
+
+// awaitRun is the state of an Await that spans its stages, nil if the execution needs none.
+type awaitRun struct {
+	saga       *saga
+	checkpoint *checkpoint
+	// saved are the names of the tasks saved by an earlier Await.
+	saved map[string]bool
+}
+
+func (e Execution) newAwaitRun() (*awaitRun, error) {
+	saga := e.newSaga()
+	if saga == nil && e.checkpoint == nil {
+		return nil, nil
+	}
+	run := &awaitRun{
+		saga:       saga,
+		checkpoint: e.checkpoint,
+	}
+	if e.checkpoint != nil {
+		names, err := e.checkpoint.store.Load(e.checkpoint.id)
 		if err != nil {
-			break
+			return nil, fmt.Errorf("checkpoint %s: %w", e.checkpoint.id, err)
+		}
+		run.saved = make(map[string]bool, len(names))
+		for _, name := range names {
+			run.saved[name] = true
+		}
+	}
+	return run, nil
+}
+
+// skip returns whether the task was saved by an earlier Await, in which case it succeeds without
+// running.
+func (r *awaitRun) skip(stage int, taskId int, task TaskExecution) bool {
+	if r == nil || !r.saved[taskName(stage, taskId, task.options.name)] {
+		return false
+	}
+	r.saga.record(stage, task, TaskResult{id: taskId})
+	return true
+}
+
+// done records the outcome of a task, and returns the error of the store if it could not be saved.
+func (r *awaitRun) done(stage int, task TaskExecution, taskResult TaskResult) error {
+	if r == nil {
+		return taskResult.err
+	}
+	r.saga.record(stage, task, taskResult)
+	if r.checkpoint == nil || taskResult.err != nil {
+		return taskResult.err
+	}
+	name := taskName(stage, taskResult.id, task.options.name)
+	if err := r.checkpoint.store.Save(r.checkpoint.id, name); err != nil {
+		return fmt.Errorf("checkpoint %s: %s: %w", r.checkpoint.id, name, err)
+	}
+	return nil
+}
+
+func (r *awaitRun) compensate(ctx context.Context, err error) error {
+	if r == nil {
+		return err
+	}
+	return r.saga.compensate(ctx, err)
+}
+
+func (e Execution) awaitParallel(ctx context.Context, stage int, currTaskList []TaskExecution, execErr []error, run *awaitRun) (error, bool) {
+	resultsChn := make(chan TaskResult, len(currTaskList))
+	submitted := 0
+	for j, task := range currTaskList {
+		// the outcome of a skipped task is recorded by skip and its error slot stays nil, as in
+		// awaitSerial
+		if run.skip(stage, j, task) {
+			continue
+		}
+		e.execute(ctx, task, stage, j, resultsChn)
+		submitted++
+	}
+	for ; submitted > 0; submitted-- {
+		select {
+		case taskResult := <-resultsChn:
+			e.observeResult(stage, currTaskList[taskResult.id], taskResult)
+			execErr[taskResult.id] = run.done(stage, currTaskList[taskResult.id], taskResult)
+		case <-ctx.Done():
+			return nil, true
+		}
+	}
+	close(resultsChn)
+	var err error
+	for j := range execErr {
+		if execErr[j] != nil {
+			if panicErr, ok := execErr[stage].(PanicError); ok {
+				return panicErr, false
+			} else if err == nil {
+				err = execErr[j]
+			} else {
+				err = fmt.Errorf("%s:%w", execErr[j], err)
+			}
+		}
+	}
+	return err, false
+}
+
+func (e Execution) awaitSerial(ctx context.Context, stage int, currTaskList []TaskExecution, execErr []error, run *awaitRun) (error, bool) {
+	resultsChn := make(chan TaskResult, 1)
+	for j, task := range currTaskList {
+		if run.skip(stage, j, task) {
+			continue
+		}
+		e.execute(ctx, task, stage, j, resultsChn)
+		select {
+		case taskResult := <-resultsChn:
+			e.observeResult(stage, task, taskResult)
+			execErr[j] = run.done(stage, task, taskResult)
+		case <-ctx.Done():
+			return nil, true
+		}
+		if execErr[j] != nil {
+			close(resultsChn)
+			return execErr[j], false
 		}
 	}
-	return ret, err
+	close(resultsChn)
+	return nil, false
 }
 
 func ExecuteParallel(tasks ...TaskExecution) Execution {
@@ -51,6 +51,7 @@ func BenchmarkExecuteSerial_Async(b *testing.B) {
 
 func BenchmarkExecuteSerial_Pool(b *testing.B) {
 	var pe = NewPoolExecutor(2, 10)
+	defer pe.Close()
 	for n := 0; n < b.N; n++ {
 		var task1Func TaskFunc = func(ctx context.Context) error {
 			return nil
@@ -118,6 +119,7 @@ func BenchmarkExecuteParallel_Async(b *testing.B) {
 
 func BenchmarkExecuteParallel_Pool(b *testing.B) {
 	var pe = NewPoolExecutor(2, 10)
+	defer pe.Close()
 	for n := 0; n < b.N; n++ {
 		var task1Func TaskFunc = func(ctx context.Context) error {
 			return nil
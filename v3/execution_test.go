@@ -26,6 +26,7 @@ func TestExecuteSerial(t *testing.T) {
 		return nil
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Immediate(), t2.Immediate().Tracing("test")},
 		{t1.Async(), t2.Async().Tracing("test")},
@@ -61,6 +62,7 @@ func TestExecuteSerial_CanAbortWithError(t *testing.T) {
 		return nil
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Immediate(), t2.Immediate(), t3.Immediate()},
 		{t1.Async(), t2.Async(), t3.Async()},
@@ -88,6 +90,7 @@ func TestExecuteParallel(t *testing.T) {
 	}
 
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Async(), t2.Async()},
 		{t1.Pool(pe), t2.Pool(pe)},
@@ -151,6 +154,7 @@ func TestExecuteParallel_Error(t *testing.T) {
 		return errors.New("test")
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Async(), t2.Async(), t3.Async(), t4.Async()},
 		{t1.Pool(pe), t2.Pool(pe), t3.Pool(pe), t4.Pool(pe)},
@@ -196,6 +200,7 @@ func TestCascade_SerialAfterParallel(t *testing.T) {
 		return nil
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Async(), t2.Async(), t3.Async(), t4.Async()},
 		{t1.Pool(pe), t2.Pool(pe), t3.Pool(pe), t4.Pool(pe)},
@@ -247,6 +252,7 @@ func TestCascade_ParallelAfterSerial(t *testing.T) {
 		return nil
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Async(), t2.Async(), t3.Async(), t4.Async()},
 		{t1.Pool(pe), t2.Pool(pe), t3.Pool(pe), t4.Pool(pe)},
@@ -303,6 +309,7 @@ func TestCascade_SerialAfterParallelError(t *testing.T) {
 		return nil
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Async(), t2.Async(), t3.Async(), t4.Async()},
 		{t1.Pool(pe), t2.Pool(pe), t3.Pool(pe), t4.Pool(pe)},
@@ -359,6 +366,7 @@ func TestCascade_SerialAfterParallelAfterParallel(t *testing.T) {
 		return nil
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Async(), t2.Async(), t3.Async(), t4.Async(), t5.Async(), t6.Async()},
 		{t1.Pool(pe), t2.Pool(pe), t3.Pool(pe), t4.Pool(pe), t5.Pool(pe), t6.Pool(pe)},
@@ -426,6 +434,7 @@ func TestExecution_Async(t *testing.T) {
 		return nil
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Async(), t2.Async(), t3.Async(), t4.Async()},
 		{t1.Pool(pe), t2.Pool(pe), t3.Pool(pe), t4.Pool(pe)},
@@ -465,6 +474,7 @@ func TestExecution_Async(t *testing.T) {
 
 func TestExecuteParallel_WithCancel(t *testing.T) {
 	var pe = NewPoolExecutor(2, 10)
+	defer pe.Close()
 	outer := 1
 	var task1 TaskFunc = func(ctx context.Context) error {
 		time.Sleep(1000 * time.Millisecond)
@@ -496,6 +506,7 @@ func TestExecuteParallel_WithCancel(t *testing.T) {
 
 func TestExecuteSerial_WithCancel(t *testing.T) {
 	var pe = NewPoolExecutor(2, 10)
+	defer pe.Close()
 	outer := 1
 	var task1 TaskFunc = func(ctx context.Context) error {
 		time.Sleep(1000 * time.Millisecond)
@@ -525,6 +536,28 @@ func TestExecuteSerial_WithCancel(t *testing.T) {
 	assertTrue(t, elapsed < 1000*time.Millisecond)
 }
 
+func TestExecution_AwaitCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	var blocking TaskFunc = func(ctx context.Context) error {
+		cancel()
+		<-release
+		return nil
+	}
+	var noop TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	_, err := ExecuteSerial(blocking.Async(), noop.Async()).Await(ctx)
+	assertNil(t, err)
+
+	// an execution with compensations returns the context error, even with nothing to compensate
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	_, err = ExecuteSerial(blocking.Async().Compensate(noop, RetryPolicy{}), noop.Async()).Await(ctx)
+	close(release)
+	assertEqual(t, context.Canceled, err)
+}
+
 func TestExecution_Switch(t *testing.T) {
 	var taskResult string
 	var defaultCase TaskFunc = func(ctx context.Context) error {
@@ -649,6 +682,7 @@ func TestExecution_Panic(t *testing.T) {
 		panic("test")
 	}
 	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
 	taskExecs := [][]TaskExecution{
 		{t1.Immediate(), t2.Immediate(), t3.Immediate(), t4.Immediate().Recover()},
 		{t1.Async(), t2.Async(), t3.Async(), t4.Async().Recover()},
@@ -0,0 +1,97 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// movingAverage is an exponentially weighted moving average of durations, each sample weighing
+// 1/8. The first sample sets the average.
+type movingAverage struct {
+	bits uint64
+}
+
+func (m *movingAverage) observe(d time.Duration) {
+	for {
+		old := atomic.LoadUint64(&m.bits)
+		avg := float64(d)
+		if old != 0 {
+			prev := math.Float64frombits(old)
+			avg = prev + (avg-prev)/8
+		}
+		if avg == 0 {
+			// zero is the value of an empty average, keep the smallest positive one instead
+			avg = math.SmallestNonzeroFloat64
+		}
+		if atomic.CompareAndSwapUint64(&m.bits, old, math.Float64bits(avg)) {
+			return
+		}
+	}
+}
+
+func (m *movingAverage) value() time.Duration {
+	return time.Duration(math.Float64frombits(atomic.LoadUint64(&m.bits)))
+}
+
+// ExecutionStats are the counts of the executions awaited since PublishExecutionStats was called.
+type ExecutionStats struct {
+	InFlight int64 `json:"inFlight"`
+	// Completed is the number of executions awaited to the end, Failed and Panicked the number of
+	// those that returned an error and whose error was a task panic.
+	Completed uint64 `json:"completed"`
+	Failed    uint64 `json:"failed"`
+	Panicked  uint64 `json:"panicked"`
+	// Latency is the moving average of the time the executions took.
+	Latency time.Duration `json:"latency"`
+}
+
+type executionTracker struct {
+	enabled   int32
+	inFlight  int64
+	completed uint64
+	failed    uint64
+	panicked  uint64
+	latency   movingAverage
+}
+
+var executions executionTracker
+
+func (t *executionTracker) await(ctx context.Context, e Execution) (ExecutionResults, error) {
+	atomic.AddInt64(&t.inFlight, 1)
+	start := time.Now()
+	results, err := e.await(ctx)
+	t.latency.observe(time.Since(start))
+	atomic.AddUint64(&t.completed, 1)
+	if errors.As(err, &PanicError{}) {
+		atomic.AddUint64(&t.panicked, 1)
+	} else if err != nil {
+		atomic.AddUint64(&t.failed, 1)
+	}
+	atomic.AddInt64(&t.inFlight, -1)
+	return results, err
+}
+
+// PublishExecutionStats starts counting the executions awaited and publishes the counts as the
+// expvar variable with the given name. Like expvar.Publish, it panics if the name is already in use.
+func PublishExecutionStats(name string) {
+	atomic.StoreInt32(&executions.enabled, 1)
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return GlobalExecutionStats()
+	}))
+}
+
+// GlobalExecutionStats returns the counts of the executions awaited since PublishExecutionStats was
+// called, which are all zero if it was not.
+func GlobalExecutionStats() ExecutionStats {
+	return ExecutionStats{
+		InFlight:  atomic.LoadInt64(&executions.inFlight),
+		Completed: atomic.LoadUint64(&executions.completed),
+		Failed:    atomic.LoadUint64(&executions.failed),
+		Panicked:  atomic.LoadUint64(&executions.panicked),
+		Latency:   executions.latency.value(),
+	}
+}
@@ -0,0 +1,49 @@
+package koncurrent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+)
+
+func TestPublishExecutionStats(t *testing.T) {
+	PublishExecutionStats("koncurrent_test_executions")
+	before := GlobalExecutionStats()
+	var succeeded TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var failed TaskFunc = func(ctx context.Context) error {
+		return errors.New("failed")
+	}
+	var panicked TaskFunc = func(ctx context.Context) error {
+		panic("panicked")
+	}
+	for _, task := range []TaskFunc{succeeded, failed, panicked} {
+		ExecuteSerial(task.Immediate()).Await(context.Background())
+	}
+	// a panic wrapped in a SagaError
+	_, err := ExecuteSerial(succeeded.Immediate().Compensate(succeeded, RetryPolicy{}), panicked.Immediate()).Await(context.Background())
+	assertTrue(t, errors.As(err, &SagaError{}))
+	after := GlobalExecutionStats()
+	assertEqual(t, int64(0), after.InFlight)
+	assertEqual(t, uint64(4), after.Completed-before.Completed)
+	assertEqual(t, uint64(1), after.Failed-before.Failed)
+	assertEqual(t, uint64(2), after.Panicked-before.Panicked)
+
+	var published ExecutionStats
+	assertNil(t, json.Unmarshal([]byte(expvar.Get("koncurrent_test_executions").String()), &published))
+	assertEqual(t, after.Completed, published.Completed)
+}
+
+func TestPoolExecutor_PublishStats(t *testing.T) {
+	pe := NewPoolExecutor(3, 5)
+	defer pe.Close()
+	pe.PublishStats("koncurrent_test_pool")
+	var published map[string]interface{}
+	assertNil(t, json.Unmarshal([]byte(expvar.Get("koncurrent_test_pool").String()), &published))
+	assertEqual(t, float64(3), published["workers"])
+	assertEqual(t, float64(5), published["queueCapacity"])
+	assertEqual(t, float64(0), published["completed"])
+}
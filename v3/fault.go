@@ -0,0 +1,180 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var ErrInjectedFault = errors.New("injected fault")
+
+type FaultKind int
+
+const (
+	FaultNone FaultKind = iota
+	// FaultLatency delays the task before running it.
+	FaultLatency
+	// FaultError fails the task without running it.
+	FaultError
+	// FaultPanic panics in place of the task.
+	FaultPanic
+	// FaultCancel runs the task with an already cancelled context.
+	FaultCancel
+)
+
+func (k FaultKind) String() string {
+	switch k {
+	case FaultNone:
+		return "none"
+	case FaultLatency:
+		return "latency"
+	case FaultError:
+		return "error"
+	case FaultPanic:
+		return "panic"
+	case FaultCancel:
+		return "cancel"
+	default:
+		return "unknown"
+	}
+}
+
+type Fault struct {
+	Kind FaultKind
+	// Latency is the delay of FaultLatency.
+	Latency time.Duration
+	// Err is the error of FaultError. Defaults to ErrInjectedFault.
+	Err error
+}
+
+// FaultRule injects its fault with the given probability into the tasks with the given name, or
+// into all tasks if the name is empty.
+type FaultRule struct {
+	Fault
+	Task        string
+	Probability float64
+}
+
+// ScheduledFault injects its fault into the Call-th execution, counting from 0, of the tasks with
+// the given name, or of all tasks if the name is empty.
+type ScheduledFault struct {
+	Fault
+	Task string
+	Call int
+}
+
+type FaultOptions struct {
+	// Seed seeds the source deciding the probabilistic faults, so that a run can be reproduced.
+	Seed int64
+	// Schedule takes precedence over Rules, and the first rule that fires is the one applied.
+	Schedule []ScheduledFault
+	Rules    []FaultRule
+	// Clock times the injected latency. Defaults to the clock of the task context.
+	Clock Clock
+}
+
+type InjectedFault struct {
+	Task string
+	Call int
+	Fault
+}
+
+// FaultInjectingExecutor wraps an executor and makes some of its tasks misbehave on purpose.
+type FaultInjectingExecutor struct {
+	executor TaskExecutor
+	options  FaultOptions
+	mu       sync.Mutex
+	rand     *rand.Rand
+	calls    map[string]int
+	total    int
+	report   []InjectedFault
+}
+
+func NewFaultInjectingExecutor(executor TaskExecutor, options FaultOptions) *FaultInjectingExecutor {
+	return &FaultInjectingExecutor{
+		executor: executor,
+		options:  options,
+		rand:     rand.New(rand.NewSource(options.Seed)),
+		calls:    make(map[string]int),
+	}
+}
+
+func (f *FaultInjectingExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	fault := f.decide(opt.name)
+	if fault.Kind == FaultNone {
+		f.executor.Execute(ctx, taskFunc, taskId, resultChn, opt)
+		return
+	}
+	f.executor.Execute(ctx, func(ctx context.Context) error {
+		switch fault.Kind {
+		case FaultLatency:
+			clock := f.options.Clock
+			if clock == nil {
+				clock = ClockFromContext(ctx)
+			}
+			timer := clock.NewTimer(fault.Latency)
+			defer timer.Stop()
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case FaultError:
+			if fault.Err == nil {
+				return ErrInjectedFault
+			}
+			return fault.Err
+		case FaultPanic:
+			panic(ErrInjectedFault)
+		case FaultCancel:
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+			ctx = cancelled
+		}
+		return taskFunc(ctx)
+	}, taskId, resultChn, opt)
+}
+
+func (f *FaultInjectingExecutor) decide(name string) Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	call := f.calls[name]
+	f.calls[name] = call + 1
+	total := f.total
+	f.total++
+	fault := Fault{}
+	for _, scheduled := range f.options.Schedule {
+		if (len(scheduled.Task) == 0 && scheduled.Call == total) || (len(scheduled.Task) > 0 && scheduled.Task == name && scheduled.Call == call) {
+			fault = scheduled.Fault
+			break
+		}
+	}
+	if fault.Kind == FaultNone {
+		for _, rule := range f.options.Rules {
+			if len(rule.Task) > 0 && rule.Task != name {
+				continue
+			}
+			if f.rand.Float64() < rule.Probability {
+				fault = rule.Fault
+				break
+			}
+		}
+	}
+	if fault.Kind != FaultNone {
+		f.report = append(f.report, InjectedFault{
+			Task:  name,
+			Call:  call,
+			Fault: fault,
+		})
+	}
+	return fault
+}
+
+// Report returns the injected faults in the order they were decided.
+func (f *FaultInjectingExecutor) Report() []InjectedFault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]InjectedFault(nil), f.report...)
+}
@@ -0,0 +1,88 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFaultInjectingExecutor_Schedule(t *testing.T) {
+	testErr := errors.New("test")
+	clock := newFakeClock()
+	underTest := NewFaultInjectingExecutor(AsyncExecutor{}, FaultOptions{
+		Schedule: []ScheduledFault{
+			{Task: "db", Call: 1, Fault: Fault{Kind: FaultError, Err: testErr}},
+			{Task: "db", Call: 2, Fault: Fault{Kind: FaultPanic}},
+			{Task: "db", Call: 3, Fault: Fault{Kind: FaultCancel}},
+			{Task: "db", Call: 4, Fault: Fault{Kind: FaultLatency, Latency: time.Second}},
+		},
+		Clock: clock,
+	})
+	var task TaskFunc = func(ctx context.Context) error {
+		return ctx.Err()
+	}
+	execution := ExecuteSerial(task.Executor(underTest).Name("db"))
+	_, err := execution.Await(context.Background())
+	assertNil(t, err)
+	_, err = execution.Await(context.Background())
+	assertEqual(t, testErr, err)
+	_, err = execution.Await(context.Background())
+	_, ok := err.(PanicError)
+	assertTrue(t, ok)
+	_, err = execution.Await(context.Background())
+	assertEqual(t, context.Canceled, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := execution.Await(context.Background())
+		done <- err
+	}()
+	clock.blockUntil(1)
+	select {
+	case <-done:
+		t.Error("task finished before the injected latency")
+	default:
+	}
+	clock.Advance(time.Second)
+	assertNil(t, <-done)
+
+	report := underTest.Report()
+	assertEqual(t, 4, len(report))
+	assertEqual(t, "db", report[0].Task)
+	assertEqual(t, 1, report[0].Call)
+	assertEqual(t, FaultError, report[0].Kind)
+	assertEqual(t, FaultLatency, report[3].Kind)
+	assertEqual(t, "latency", report[3].Kind.String())
+}
+
+func TestFaultInjectingExecutor_Rules(t *testing.T) {
+	var reports [][]InjectedFault
+	for i := 0; i < 2; i++ {
+		underTest := NewFaultInjectingExecutor(ImmediateExecutor{}, FaultOptions{
+			Seed: 7,
+			Rules: []FaultRule{
+				{Task: "cache", Probability: 1, Fault: Fault{Kind: FaultError}},
+				{Probability: 0.5, Fault: Fault{Kind: FaultError}},
+			},
+		})
+		var task TaskFunc = func(ctx context.Context) error {
+			return nil
+		}
+		for j := 0; j < 20; j++ {
+			_, _ = ExecuteParallel(task.Executor(underTest).Name("db"), task.Executor(underTest).Name("cache")).Await(context.Background())
+		}
+		reports = append(reports, underTest.Report())
+	}
+	assertEqual(t, len(reports[0]), len(reports[1]))
+	cacheFaults := 0
+	for i := range reports[0] {
+		assertEqual(t, reports[0][i].Task, reports[1][i].Task)
+		assertEqual(t, reports[0][i].Call, reports[1][i].Call)
+		if reports[0][i].Task == "cache" {
+			cacheFaults++
+		}
+	}
+	assertEqual(t, 20, cacheFaults)
+	assertTrue(t, len(reports[0]) > 20 && len(reports[0]) < 40)
+}
@@ -1,5 +1,5 @@
 module github.com/raymond852/koncurrent/v3
 
-go 1.13
+go 1.21
 
 require github.com/opentracing/opentracing-go v1.2.0
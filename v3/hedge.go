@@ -0,0 +1,109 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+type HedgeError struct {
+	Errors []error
+}
+
+func (e HedgeError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = e.Errors[i].Error()
+	}
+	return "all hedged attempts failed:" + strings.Join(msgs, ":")
+}
+
+func (e HedgeError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors[len(e.Errors)-1]
+}
+
+type hedgeExecutor struct {
+	executor TaskExecutor
+	delay    time.Duration
+	max      int
+}
+
+// Hedge starts another attempt of the task whenever it has not succeeded after delay, up to max
+// attempts in total. A failed attempt starts the next one right away. The first successful attempt
+// wins and the context of the others is cancelled.
+func (t TaskExecution) Hedge(delay time.Duration, max int) TaskExecution {
+	if max <= 1 {
+		return t
+	}
+	ret := t
+	ret.executor = hedgeExecutor{
+		executor: t.executor,
+		delay:    delay,
+		max:      max,
+	}
+	return ret
+}
+
+func (h hedgeExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	go h.run(ctx, taskFunc, taskId, resultChn, opt)
+}
+
+func (h hedgeExecutor) run(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	pprof.SetGoroutineLabels(hedgeLabels)
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	attemptChn := make(chan TaskResult, h.max)
+	launched := 0
+	launch := func() {
+		launched++
+		if launched > 1 && opt.observer != nil {
+			opt.emit(EventTaskHedge, taskId, launched, nil)
+		}
+		go h.executor.Execute(hedgeCtx, taskFunc, launched, attemptChn, opt)
+	}
+	launch()
+	timer := ClockFromContext(ctx).NewTimer(h.delay)
+	defer timer.Stop()
+	var errs []error
+	for {
+		select {
+		case attemptResult := <-attemptChn:
+			if attemptResult.err == nil {
+				resultChn <- TaskResult{
+					id: taskId,
+				}
+				return
+			}
+			errs = append(errs, attemptResult.err)
+			if len(errs) == h.max {
+				resultChn <- TaskResult{
+					err: HedgeError{Errors: errs},
+					id:  taskId,
+				}
+				return
+			}
+			if len(errs) == launched {
+				launch()
+				if !timer.Stop() {
+					<-timer.C()
+				}
+				timer.Reset(h.delay)
+			}
+		case <-timer.C():
+			if launched < h.max {
+				launch()
+				timer.Reset(h.delay)
+			}
+		case <-ctx.Done():
+			resultChn <- TaskResult{
+				err: ctx.Err(),
+				id:  taskId,
+			}
+			return
+		}
+	}
+}
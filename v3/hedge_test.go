@@ -0,0 +1,68 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTaskExecution_Hedge(t *testing.T) {
+	pe := NewPoolExecutor(10, 10)
+	defer pe.Close()
+	for _, executor := range []TaskExecutor{ImmediateExecutor{}, AsyncExecutor{}, pe} {
+		var calls int32
+		cancelled := make(chan struct{})
+		var task TaskFunc = func(ctx context.Context) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-ctx.Done()
+				close(cancelled)
+				return ctx.Err()
+			}
+			return nil
+		}
+		recorder := NewRecorder()
+		var hedges int32
+		now := time.Now()
+		results, err := ExecuteParallel(task.Executor(executor).Name("read").Hedge(20*time.Millisecond, 3)).
+			Observe(recorder).
+			Observe(ObserverFunc(func(event Event) {
+				if event.Kind == EventTaskHedge {
+					atomic.AddInt32(&hedges, 1)
+				}
+			})).
+			Await(context.Background())
+		assertNil(t, err)
+		assertNil(t, results[0][0])
+		assertTrue(t, time.Since(now) < 100*time.Millisecond)
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Error("losing attempt was not cancelled")
+		}
+		assertEqual(t, int32(2), atomic.LoadInt32(&calls))
+		assertEqual(t, int32(1), atomic.LoadInt32(&hedges))
+		records := recorder.Records()
+		assertEqual(t, 1, len(records))
+		assertEqual(t, "read", records[0].Name)
+		assertEqual(t, 2, records[0].Attempts)
+		assertTrue(t, records[0].Hedged())
+	}
+}
+
+func TestTaskExecution_HedgeAllFailed(t *testing.T) {
+	var calls int32
+	var task TaskFunc = func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("test")
+	}
+	now := time.Now()
+	_, err := ExecuteSerial(task.Async().Hedge(time.Second, 3)).Await(context.Background())
+	assertTrue(t, time.Since(now) < 100*time.Millisecond)
+	hedgeErr, ok := err.(HedgeError)
+	assertTrue(t, ok)
+	assertEqual(t, 3, len(hedgeErr.Errors))
+	assertEqual(t, int32(3), atomic.LoadInt32(&calls))
+	assertEqual(t, "test", errors.Unwrap(err).Error())
+}
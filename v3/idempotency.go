@@ -0,0 +1,166 @@
+package koncurrent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DedupStore keeps the idempotency keys of the tasks that succeeded. Implementations must be safe
+// for concurrent use.
+type DedupStore interface {
+	// Completed returns whether a task with the key succeeded.
+	Completed(key string) (bool, error)
+	// Complete records that a task with the key succeeded.
+	Complete(key string) error
+}
+
+var DefaultDedupStore DedupStore = NewMemoryDedupStore()
+
+type MemoryDedupStore struct {
+	mu       sync.Mutex
+	keys     map[string]bool
+	coalesce coalesceGroup
+}
+
+func NewMemoryDedupStore() *MemoryDedupStore {
+	return &MemoryDedupStore{
+		keys: make(map[string]bool),
+	}
+}
+
+func (s *MemoryDedupStore) Completed(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key], nil
+}
+
+func (s *MemoryDedupStore) Complete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key] = true
+	return nil
+}
+
+func (s *MemoryDedupStore) coalescer() *coalesceGroup {
+	return &s.coalesce
+}
+
+// FileDedupStore keeps the keys in memory and in a file, one quoted key per line, which it reads
+// back when opened. Each Complete appends a line and syncs the file, and a last line cut short by a
+// crash is ignored.
+type FileDedupStore struct {
+	path     string
+	mu       sync.Mutex
+	keys     map[string]bool
+	coalesce coalesceGroup
+}
+
+// OpenFileDedupStore returns a store of the keys in the file at path, creating the file and its
+// directory if needed.
+func OpenFileDedupStore(path string) (*FileDedupStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	keys, err := readQuotedLines(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileDedupStore{
+		path: path,
+		keys: make(map[string]bool, len(keys)),
+	}
+	for _, key := range keys {
+		s.keys[key] = true
+	}
+	return s, nil
+}
+
+func (s *FileDedupStore) Completed(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key], nil
+}
+
+func (s *FileDedupStore) Complete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys[key] {
+		return nil
+	}
+	if err := appendQuotedLine(s.path, key); err != nil {
+		return err
+	}
+	s.keys[key] = true
+	return nil
+}
+
+func (s *FileDedupStore) coalescer() *coalesceGroup {
+	return &s.coalesce
+}
+
+type dedupExecutor struct {
+	executor TaskExecutor
+	store    DedupStore
+	key      string
+}
+
+// IdempotencyKey skips the task when a task with the same key already succeeded, according to
+// DefaultDedupStore, and records the key once the task succeeds. A skipped task succeeds and is
+// reported to the observers of the execution as deduplicated. Tasks with the key running at the
+// same time share the outcome of a single run when the store is a MemoryDedupStore or a
+// FileDedupStore.
+func (t TaskExecution) IdempotencyKey(key string) TaskExecution {
+	return t.IdempotencyKeyWith(DefaultDedupStore, key)
+}
+
+// IdempotencyKeyWith is like IdempotencyKey but uses the given store.
+func (t TaskExecution) IdempotencyKeyWith(store DedupStore, key string) TaskExecution {
+	ret := t
+	ret.executor = dedupExecutor{
+		executor: t.executor,
+		store:    store,
+		key:      key,
+	}
+	return ret
+}
+
+func (d dedupExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	completed, err := d.store.Completed(d.key)
+	if err != nil {
+		resultChn <- TaskResult{
+			err: fmt.Errorf("idempotency key %s: %w", d.key, err),
+			id:  taskId,
+		}
+		return
+	}
+	if completed {
+		if opt.observer != nil {
+			opt.emit(EventTaskDeduplicated, taskId, 0, nil)
+		}
+		resultChn <- TaskResult{
+			id: taskId,
+		}
+		return
+	}
+	run := func(ctx context.Context) error {
+		// a task with the key may have completed since the store was consulted
+		if completed, err := d.store.Completed(d.key); err != nil || completed {
+			return err
+		}
+		if err := taskFunc(ctx); err != nil {
+			return err
+		}
+		if err := d.store.Complete(d.key); err != nil {
+			return fmt.Errorf("idempotency key %s: %w", d.key, err)
+		}
+		return nil
+	}
+	if store, ok := d.store.(coalescing); ok {
+		store.coalescer().execute(ctx, d.key, d.executor, run, taskId, resultChn, opt)
+		return
+	}
+	d.executor.Execute(ctx, run, taskId, resultChn, opt)
+}
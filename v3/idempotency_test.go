@@ -0,0 +1,115 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTaskExecution_IdempotencyKey(t *testing.T) {
+	store := NewMemoryDedupStore()
+	var runs int32
+	var charge TaskFunc = func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+	execution := ExecuteSerial(charge.Async().Name("charge").IdempotencyKeyWith(store, "order-42/charge"))
+
+	recorder := NewRecorder()
+	_, err := execution.Observe(recorder).Await(context.Background())
+	assertNil(t, err)
+	assertTrue(t, !recorder.Records()[0].Deduplicated)
+
+	// the whole execution is run again
+	recorder = NewRecorder()
+	results, err := execution.Observe(recorder).Await(context.Background())
+	assertNil(t, err)
+	assertNil(t, results[0][0])
+	assertEqual(t, int32(1), atomic.LoadInt32(&runs))
+	assertTrue(t, recorder.Records()[0].Deduplicated)
+}
+
+func TestTaskExecution_IdempotencyKeyFailed(t *testing.T) {
+	store := NewMemoryDedupStore()
+	var runs int32
+	var charge TaskFunc = func(ctx context.Context) error {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			return errors.New("card declined")
+		}
+		return nil
+	}
+	execution := ExecuteSerial(charge.Immediate().IdempotencyKeyWith(store, "order-42/charge"))
+	_, err := execution.Await(context.Background())
+	assertNotNil(t, err)
+	_, err = execution.Await(context.Background())
+	assertNil(t, err)
+	_, err = execution.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, int32(2), atomic.LoadInt32(&runs))
+}
+
+func TestTaskExecution_IdempotencyKeyConcurrent(t *testing.T) {
+	store := NewMemoryDedupStore()
+	var runs int32
+	release := make(chan struct{})
+	var charge TaskFunc = func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	}
+	// the immediate tasks of a parallel stage are submitted in order, so both charges have joined
+	// the run by the time the last task releases it
+	var releasing TaskFunc = func(ctx context.Context) error {
+		close(release)
+		return nil
+	}
+	results, err := ExecuteParallel(
+		charge.Immediate().IdempotencyKeyWith(store, "order-42/charge"),
+		charge.Immediate().IdempotencyKeyWith(store, "order-42/charge"),
+		releasing.Immediate(),
+	).Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 0, len(results.FlattenErrors()))
+	assertEqual(t, int32(1), atomic.LoadInt32(&runs))
+}
+
+func TestFileDedupStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup", "keys")
+	store, err := OpenFileDedupStore(path)
+	assertNil(t, err)
+	completed, err := store.Completed("order-42/charge")
+	assertNil(t, err)
+	assertTrue(t, !completed)
+	assertNil(t, store.Complete("order-42/charge"))
+	assertNil(t, store.Complete("order-42/charge"))
+
+	// the keys outlive the process
+	store, err = OpenFileDedupStore(path)
+	assertNil(t, err)
+	completed, err = store.Completed("order-42/charge")
+	assertNil(t, err)
+	assertTrue(t, completed)
+	lines, err := readQuotedLines(path)
+	assertNil(t, err)
+	assertEqual(t, 1, len(lines))
+
+	// a key cut short by a crash is ignored, and cut off by the next key
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assertNil(t, err)
+	f.WriteString(`"order-43/cha`)
+	f.Close()
+	store, err = OpenFileDedupStore(path)
+	assertNil(t, err)
+	assertNil(t, store.Complete("order-44/charge"))
+	store, err = OpenFileDedupStore(path)
+	assertNil(t, err)
+	completed, err = store.Completed("order-44/charge")
+	assertNil(t, err)
+	assertTrue(t, completed)
+	lines, err = readQuotedLines(path)
+	assertNil(t, err)
+	assertEqual(t, 2, len(lines))
+}
@@ -26,6 +26,13 @@ func (p ImmediateExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskI
 		c = spanCtx
 		defer span.Finish()
 	}
+	if labels := taskLabels(false, opt, taskId, "immediate", ""); labels != nil {
+		resultChn <- TaskResult{
+			err: runLabelled(c, taskFunc, labels, ctx),
+			id:  taskId,
+		}
+		return
+	}
 	resultChn <- TaskResult{
 		err: taskFunc(c),
 		id:  taskId,
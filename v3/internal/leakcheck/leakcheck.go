@@ -0,0 +1,66 @@
+// Package leakcheck finds the goroutines started by koncurrent, told apart by the pprof label the
+// library sets on them, that are still running.
+package leakcheck
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const label = `"koncurrent":"`
+
+// Snapshot counts the labelled goroutines by stack.
+type Snapshot map[string]int
+
+// Take returns the labelled goroutines running now.
+func Take() Snapshot {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	ret := Snapshot{}
+	profile := buf.String()
+	// skip the "goroutine profile: total 12" header
+	if i := strings.IndexByte(profile, '\n'); i >= 0 {
+		profile = profile[i+1:]
+	}
+	for _, record := range strings.Split(profile, "\n\n") {
+		if !strings.Contains(record, label) {
+			continue
+		}
+		// a record starts with the number of goroutines sharing the stack: "2 @ 0x43a1e5 0x40b2c6"
+		i := strings.Index(record, " @ ")
+		if i < 0 {
+			continue
+		}
+		count, err := strconv.Atoi(record[:i])
+		if err != nil {
+			continue
+		}
+		ret[strings.TrimSpace(record[i+3:])] += count
+	}
+	return ret
+}
+
+// Leaks waits up to timeout for the labelled goroutines that were not in the snapshot to exit and
+// returns the stacks of those still running.
+func (s Snapshot) Leaks(timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	backoff := time.Millisecond
+	for {
+		var leaks []string
+		for stack, count := range Take() {
+			if count > s[stack] {
+				leaks = append(leaks, strconv.Itoa(count-s[stack])+" @ "+stack)
+			}
+		}
+		if len(leaks) == 0 || time.Now().After(deadline) {
+			return leaks
+		}
+		time.Sleep(backoff)
+		if backoff < 100*time.Millisecond {
+			backoff *= 2
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package leakcheck
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_Leaks(t *testing.T) {
+	snapshot := Take()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pprof.Do(context.Background(), pprof.Labels("koncurrent", "test"), func(ctx context.Context) {
+		go func() {
+			close(started)
+			<-release
+		}()
+	})
+	<-started
+	go func() {
+		// unlabelled goroutines are ignored
+		<-release
+	}()
+	leaks := snapshot.Leaks(10 * time.Millisecond)
+	if len(leaks) != 1 {
+		t.Fatalf("expected 1 leaked goroutine, found %d", len(leaks))
+	}
+	close(release)
+	if leaks := snapshot.Leaks(time.Second); len(leaks) != 0 {
+		t.Errorf("unexpected leaked goroutines %v", leaks)
+	}
+}
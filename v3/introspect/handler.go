@@ -0,0 +1,49 @@
+package introspect
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+var page = template.Must(template.New("koncurrent").Parse(`<!DOCTYPE html>
+<html>
+<head><title>koncurrent</title></head>
+<body>
+<h1>Executions in flight</h1>
+{{range .Executions}}
+<h2>#{{.ID}} {{.Name}}</h2>
+<p>stage {{.Stage}} of {{.Stages}}, running for {{.Elapsed}}</p>
+<table>
+<tr><th>stage</th><th>task</th><th>state</th><th>elapsed</th></tr>
+{{range .Tasks}}<tr><td>{{.Stage}}</td><td>{{.Name}}</td><td>{{.State}}</td><td>{{.Elapsed}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>none</p>
+{{end}}
+<h1>Pools</h1>
+<table>
+<tr><th>name</th><th>workers</th><th>busy</th><th>queued</th><th>queue capacity</th></tr>
+{{range .Pools}}<tr><td>{{.Name}}</td><td>{{.Workers}}</td><td>{{.Busy}}</td><td>{{.Queued}}</td><td>{{.QueueCapacity}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Handler serves a snapshot of the registry, as JSON when the request has a format=json query
+// parameter or accepts application/json, and as an HTML page otherwise.
+func Handler(registry *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snapshot := registry.Snapshot()
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(snapshot)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := page.Execute(w, snapshot); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
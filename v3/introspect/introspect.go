@@ -0,0 +1,214 @@
+// Package introspect tracks the executions in flight and the pools of a process, and serves them
+// over HTTP like net/http/pprof:
+//
+//	http.Handle("/debug/koncurrent/", introspect.Handler(introspect.DefaultRegistry))
+//
+// Executions are tracked when awaited through a Registry, pools once registered with it.
+package introspect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/raymond852/koncurrent/v3"
+)
+
+// PoolStatser is a pool reporting its occupancy, such as koncurrent.PoolExecutor.
+type PoolStatser interface {
+	Stats() koncurrent.PoolStats
+}
+
+type Registry struct {
+	mu     sync.Mutex
+	nextID uint64
+	awaits map[uint64]*trackedAwait
+	pools  map[string]PoolStatser
+}
+
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		awaits: make(map[uint64]*trackedAwait),
+		pools:  make(map[string]PoolStatser),
+	}
+}
+
+// Execution is the state of an execution in flight. Durations are encoded in JSON as nanoseconds.
+type Execution struct {
+	ID      uint64        `json:"id"`
+	Name    string        `json:"name"`
+	Started time.Time     `json:"started"`
+	Elapsed time.Duration `json:"elapsed"`
+	// Stage is the index of the stage in progress, out of Stages.
+	Stage  int    `json:"stage"`
+	Stages int    `json:"stages"`
+	Tasks  []Task `json:"tasks"`
+}
+
+// Task is a task of the stage in progress that has not finished yet.
+type Task struct {
+	Stage int    `json:"stage"`
+	Task  int    `json:"task"`
+	Name  string `json:"name"`
+	// State is "queued" until the task starts running, then "running".
+	State   string        `json:"state"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+type Pool struct {
+	Name string `json:"name"`
+	koncurrent.PoolStats
+}
+
+type Snapshot struct {
+	Executions []Execution `json:"executions"`
+	Pools      []Pool      `json:"pools"`
+}
+
+type trackedAwait struct {
+	mu      sync.Mutex
+	id      uint64
+	name    string
+	started time.Time
+	stages  int
+	stage   int
+	tasks   map[[2]int]*trackedTask
+}
+
+type trackedTask struct {
+	name    string
+	running bool
+	since   time.Time
+}
+
+// Await awaits the execution, tracking it until it returns.
+func (r *Registry) Await(ctx context.Context, execution koncurrent.Execution) (koncurrent.ExecutionResults, error) {
+	plan := execution.Describe()
+	r.mu.Lock()
+	r.nextID++
+	await := &trackedAwait{
+		id:      r.nextID,
+		name:    plan.Name,
+		started: time.Now(),
+		stages:  len(plan.Stages),
+		tasks:   make(map[[2]int]*trackedTask),
+	}
+	r.awaits[await.id] = await
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.awaits, await.id)
+		r.mu.Unlock()
+	}()
+	return execution.Observe(await).Await(ctx)
+}
+
+func (a *trackedAwait) Observe(event koncurrent.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := [2]int{event.Stage, event.Task}
+	switch event.Kind {
+	case koncurrent.EventStageStart:
+		a.stage = event.Stage
+	case koncurrent.EventTaskSubmit:
+		a.tasks[key] = &trackedTask{
+			name:  event.Name,
+			since: event.Time,
+		}
+	case koncurrent.EventTaskStart:
+		if task, ok := a.tasks[key]; ok && !task.running {
+			task.running = true
+			task.since = event.Time
+		}
+	case koncurrent.EventTaskResult:
+		delete(a.tasks, key)
+	}
+}
+
+// RegisterPool reports the occupancy of the pool under the given name.
+func (r *Registry) RegisterPool(name string, pool PoolStatser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pools[name] = pool
+}
+
+func (r *Registry) UnregisterPool(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pools, name)
+}
+
+// Snapshot returns the executions in flight, oldest first, and the registered pools by name.
+func (r *Registry) Snapshot() Snapshot {
+	now := time.Now()
+	r.mu.Lock()
+	awaits := make([]*trackedAwait, 0, len(r.awaits))
+	for _, await := range r.awaits {
+		awaits = append(awaits, await)
+	}
+	pools := make(map[string]PoolStatser, len(r.pools))
+	for name, pool := range r.pools {
+		pools[name] = pool
+	}
+	r.mu.Unlock()
+
+	snapshot := Snapshot{
+		Executions: make([]Execution, 0, len(awaits)),
+		Pools:      make([]Pool, 0, len(pools)),
+	}
+	for _, await := range awaits {
+		snapshot.Executions = append(snapshot.Executions, await.snapshot(now))
+	}
+	sort.Slice(snapshot.Executions, func(i, j int) bool {
+		return snapshot.Executions[i].ID < snapshot.Executions[j].ID
+	})
+	for name, pool := range pools {
+		snapshot.Pools = append(snapshot.Pools, Pool{Name: name, PoolStats: pool.Stats()})
+	}
+	sort.Slice(snapshot.Pools, func(i, j int) bool {
+		return snapshot.Pools[i].Name < snapshot.Pools[j].Name
+	})
+	return snapshot
+}
+
+func (a *trackedAwait) snapshot(now time.Time) Execution {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	execution := Execution{
+		ID:      a.id,
+		Name:    a.name,
+		Started: a.started,
+		Elapsed: now.Sub(a.started),
+		Stage:   a.stage,
+		Stages:  a.stages,
+		Tasks:   make([]Task, 0, len(a.tasks)),
+	}
+	for key, task := range a.tasks {
+		state := "queued"
+		if task.running {
+			state = "running"
+		}
+		name := task.name
+		if name == "" {
+			name = fmt.Sprintf("stage%d-task%d", key[0], key[1])
+		}
+		execution.Tasks = append(execution.Tasks, Task{
+			Stage:   key[0],
+			Task:    key[1],
+			Name:    name,
+			State:   state,
+			Elapsed: now.Sub(task.since),
+		})
+	}
+	sort.Slice(execution.Tasks, func(i, j int) bool {
+		if execution.Tasks[i].Stage != execution.Tasks[j].Stage {
+			return execution.Tasks[i].Stage < execution.Tasks[j].Stage
+		}
+		return execution.Tasks[i].Task < execution.Tasks[j].Task
+	})
+	return execution
+}
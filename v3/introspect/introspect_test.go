@@ -0,0 +1,78 @@
+package introspect
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raymond852/koncurrent/v3"
+)
+
+func TestHandler(t *testing.T) {
+	pe := koncurrent.NewPoolExecutor(2, 4)
+	defer pe.Close()
+	registry := NewRegistry()
+	registry.RegisterPool("orders", pe)
+	server := httptest.NewServer(Handler(registry))
+	defer server.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var blocking koncurrent.TaskFunc = func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}
+	var noop koncurrent.TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	done := make(chan error)
+	go func() {
+		_, err := registry.Await(context.Background(), koncurrent.ExecuteSerial(noop.Immediate().Name("auth")).
+			ExecuteParallel(blocking.Pool(pe).Name("price"), noop.Immediate()).
+			ExecuteSerial(noop.Immediate().Name("render")).
+			Name("checkout"))
+		done <- err
+	}()
+	<-started
+
+	resp, err := http.Get(server.URL + "?format=json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot Snapshot
+	assertNil(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+	resp.Body.Close()
+	assertEqual(t, 1, len(snapshot.Executions))
+	execution := snapshot.Executions[0]
+	assertEqual(t, "checkout", execution.Name)
+	assertEqual(t, 1, execution.Stage)
+	assertEqual(t, 3, execution.Stages)
+	assertEqual(t, 1, len(execution.Tasks))
+	assertEqual(t, "price", execution.Tasks[0].Name)
+	assertEqual(t, "running", execution.Tasks[0].State)
+	assertEqual(t, 1, len(snapshot.Pools))
+	assertEqual(t, "orders", snapshot.Pools[0].Name)
+	assertEqual(t, 2, snapshot.Pools[0].Workers)
+	assertEqual(t, 1, snapshot.Pools[0].Busy)
+
+	resp, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assertNil(t, err)
+	assertTrue(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html"))
+	for _, s := range []string{"#1 checkout", "stage 1 of 3", "<td>price</td><td>running</td>", "<td>orders</td><td>2</td><td>1</td>"} {
+		assertTrue(t, strings.Contains(string(body), s))
+	}
+
+	close(release)
+	assertNil(t, <-done)
+	assertEqual(t, 0, len(registry.Snapshot().Executions))
+}
@@ -0,0 +1,38 @@
+package koncurrenttest
+
+import (
+	"testing"
+)
+
+// AssertOrder fails the test unless the trace, usually StepExecutor.Trace, is exactly the given
+// task names.
+func AssertOrder(t testing.TB, trace []string, names ...string) {
+	t.Helper()
+	if len(trace) != len(names) {
+		t.Errorf("unexpected interleaving %v, expected %v", trace, names)
+		return
+	}
+	for i := range trace {
+		if trace[i] != names[i] {
+			t.Errorf("unexpected interleaving %v, expected %v", trace, names)
+			return
+		}
+	}
+}
+
+// AssertBefore fails the test unless the task named first ran before the task named second.
+func AssertBefore(t testing.TB, trace []string, first string, second string) {
+	t.Helper()
+	firstIndex, secondIndex := -1, -1
+	for i := range trace {
+		if trace[i] == first && firstIndex < 0 {
+			firstIndex = i
+		}
+		if trace[i] == second && secondIndex < 0 {
+			secondIndex = i
+		}
+	}
+	if firstIndex < 0 || secondIndex < 0 || firstIndex > secondIndex {
+		t.Errorf("expected %s to run before %s in %v", first, second, trace)
+	}
+}
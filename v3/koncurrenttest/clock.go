@@ -0,0 +1,148 @@
+package koncurrenttest
+
+import (
+	"github.com/raymond852/koncurrent/v3"
+	"sort"
+	"sync"
+	"time"
+)
+
+// VirtualClock is a koncurrent.Clock whose time only moves when Advance is called. Timers due by
+// the new time fire in the order of their deadlines.
+type VirtualClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []*virtualTimer
+}
+
+type virtualTimer struct {
+	clock *VirtualClock
+	at    time.Time
+	c     chan time.Time
+}
+
+func NewVirtualClock(start time.Time) *VirtualClock {
+	ret := &VirtualClock{
+		now: start,
+	}
+	ret.changed = sync.NewCond(&ret.mu)
+	return ret
+}
+
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *VirtualClock) NewTimer(d time.Duration) koncurrent.Timer {
+	t := &virtualTimer{
+		clock: c,
+		c:     make(chan time.Time, 1),
+	}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the time forward by d and fires the timers due by then.
+func (c *VirtualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.advanceTo(c.now.Add(d))
+	c.mu.Unlock()
+}
+
+// AdvanceToNext moves the time to the deadline of the earliest pending timer and fires it. It
+// returns false if there is no pending timer.
+func (c *VirtualClock) AdvanceToNext() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.timers) == 0 {
+		return false
+	}
+	c.advanceTo(c.timers[0].at)
+	return true
+}
+
+// Pending returns the number of timers that have not fired or been stopped.
+func (c *VirtualClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil waits until at least n timers are pending, which lets a test advance the time only
+// once the goroutines under test are waiting on it.
+func (c *VirtualClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
+
+func (c *VirtualClock) advanceTo(to time.Time) {
+	for len(c.timers) > 0 && !c.timers[0].at.After(to) {
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+	if to.After(c.now) {
+		c.now = to
+	}
+	c.changed.Broadcast()
+}
+
+func (c *VirtualClock) add(t *virtualTimer) {
+	i := sort.Search(len(c.timers), func(i int) bool {
+		return c.timers[i].at.After(t.at)
+	})
+	c.timers = append(c.timers, nil)
+	copy(c.timers[i+1:], c.timers[i:])
+	c.timers[i] = t
+	c.changed.Broadcast()
+}
+
+func (c *VirtualClock) remove(t *virtualTimer) bool {
+	for i := range c.timers {
+		if c.timers[i] == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+func (t *virtualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *virtualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *virtualTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	active := c.remove(t)
+	t.at = c.now.Add(d)
+	if d <= 0 {
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	} else {
+		c.add(t)
+	}
+	return active
+}
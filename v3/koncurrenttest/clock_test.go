@@ -0,0 +1,69 @@
+package koncurrenttest
+
+import (
+	"context"
+	"github.com/raymond852/koncurrent/v3"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVirtualClock(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	underTest := NewVirtualClock(start)
+	late := underTest.NewTimer(2 * time.Second)
+	early := underTest.NewTimer(time.Second)
+	stopped := underTest.NewTimer(time.Second)
+	assertTrue(t, stopped.Stop())
+	assertEqual(t, 2, underTest.Pending())
+
+	underTest.Advance(1500 * time.Millisecond)
+	assertEqual(t, start.Add(time.Second), <-early.C())
+	assertEqual(t, start.Add(1500*time.Millisecond), underTest.Now())
+	assertTrue(t, underTest.AdvanceToNext())
+	assertEqual(t, start.Add(2*time.Second), <-late.C())
+	assertTrue(t, !underTest.AdvanceToNext())
+	assertTrue(t, !late.Reset(time.Second))
+	assertEqual(t, 1, underTest.Pending())
+}
+
+func TestVirtualClock_Scheduler(t *testing.T) {
+	clock := NewVirtualClock(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	ran := make(chan time.Time, 1)
+	st := koncurrent.NewScheduler(koncurrent.ImmediateExecutor{}, clock).ScheduleAtFixedRate(func(ctx context.Context) error {
+		ran <- clock.Now()
+		return nil
+	}, time.Hour, time.Hour, koncurrent.MissedRunSkip)
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+		assertEqual(t, time.Duration(i)*time.Hour, (<-ran).Sub(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
+	}
+	st.Cancel()
+	<-st.Done()
+}
+
+func TestVirtualClock_Hedge(t *testing.T) {
+	clock := NewVirtualClock(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	attempts := make(chan int, 2)
+	var calls int32
+	var task koncurrent.TaskFunc = func(ctx context.Context) error {
+		call := atomic.AddInt32(&calls, 1)
+		attempts <- int(call)
+		if call == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+	done := make(chan error)
+	go func() {
+		_, err := koncurrent.ExecuteSerial(task.Async().Hedge(time.Minute, 2)).Await(koncurrent.WithClock(context.Background(), clock))
+		done <- err
+	}()
+	assertEqual(t, 1, <-attempts)
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	assertEqual(t, 2, <-attempts)
+	assertNil(t, <-done)
+}
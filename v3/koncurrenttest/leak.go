@@ -0,0 +1,53 @@
+package koncurrenttest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raymond852/koncurrent/v3/internal/leakcheck"
+)
+
+// LeakTimeout is how long the leak checks wait for the goroutines of koncurrent to exit.
+var LeakTimeout = 5 * time.Second
+
+// GoroutineSnapshot is the set of goroutines started by koncurrent, pool workers and the
+// goroutines running tasks, at some point in time.
+type GoroutineSnapshot struct {
+	snapshot leakcheck.Snapshot
+}
+
+// SnapshotGoroutines returns the goroutines started by koncurrent running now. Used as
+//
+//	defer koncurrenttest.SnapshotGoroutines().Verify(t)
+//
+// it fails the test if the executions and executors it uses leave goroutines behind once the
+// executions are awaited and the executors closed.
+func SnapshotGoroutines() GoroutineSnapshot {
+	return GoroutineSnapshot{snapshot: leakcheck.Take()}
+}
+
+// Verify fails the test if goroutines started by koncurrent after the snapshot are still running
+// after LeakTimeout.
+func (s GoroutineSnapshot) Verify(t testing.TB) {
+	t.Helper()
+	if leaks := s.snapshot.Leaks(LeakTimeout); len(leaks) > 0 {
+		t.Errorf("found leaked goroutines:\n%s", strings.Join(leaks, "\n\n"))
+	}
+}
+
+// VerifyTestMain runs the tests and exits, failing if they pass but leave goroutines started by
+// koncurrent behind. It is meant to be called from TestMain.
+func VerifyTestMain(m *testing.M) {
+	snapshot := leakcheck.Take()
+	code := m.Run()
+	if code == 0 {
+		if leaks := snapshot.Leaks(LeakTimeout); len(leaks) > 0 {
+			fmt.Fprintf(os.Stderr, "found leaked goroutines:\n%s\n", strings.Join(leaks, "\n\n"))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
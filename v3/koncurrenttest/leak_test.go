@@ -0,0 +1,19 @@
+package koncurrenttest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/raymond852/koncurrent/v3"
+)
+
+func TestGoroutineSnapshot_Verify(t *testing.T) {
+	defer SnapshotGoroutines().Verify(t)
+	pe := koncurrent.NewPoolExecutor(4, 4)
+	defer pe.Close()
+	var task koncurrent.TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	_, err := koncurrent.ExecuteParallel(task.Executor(pe), task.Executor(koncurrent.AsyncExecutor{})).Await(context.Background())
+	assertNil(t, err)
+}
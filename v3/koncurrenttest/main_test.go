@@ -0,0 +1,9 @@
+package koncurrenttest
+
+import (
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	VerifyTestMain(m)
+}
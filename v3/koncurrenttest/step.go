@@ -0,0 +1,234 @@
+package koncurrenttest
+
+import (
+	"context"
+	"fmt"
+	"github.com/raymond852/koncurrent/v3"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// settleDelay is how long Run waits for more tasks of a parallel stage once some are pending, in
+// case the others never reach the executor.
+const settleDelay = 10 * time.Millisecond
+
+// StepExecutor is a koncurrent.TaskExecutor that does not run the tasks it receives until Step is
+// called, and then runs them one at a time on the calling goroutine. The task to run next is the
+// oldest pending one, a random one picked from a seeded source, or the next one of an explicit
+// order of task names, depending on the constructor.
+type StepExecutor struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	rand    *rand.Rand
+	order   []string
+	pending []*stepTask
+	trace   []string
+	// the stage being awaited by Run, used to tell when Await is waiting for results
+	stage         int
+	stageTasks    int
+	stageParallel bool
+	submitted     int
+	// changes counts the submissions and stage starts, settled is its value when no task arrived
+	// for settleDelay
+	changes int
+	settled int
+	timer   *time.Timer
+}
+
+type stepTask struct {
+	ctx       context.Context
+	taskFunc  koncurrent.TaskFunc
+	taskId    int
+	resultChn chan koncurrent.TaskResult
+	opt       koncurrent.TaskExecutionOptions
+	name      string
+}
+
+// NewStepExecutor returns a StepExecutor running the pending tasks in submission order.
+func NewStepExecutor() *StepExecutor {
+	ret := &StepExecutor{
+		settled: -1,
+	}
+	ret.changed = sync.NewCond(&ret.mu)
+	return ret
+}
+
+// NewSeededStepExecutor returns a StepExecutor running the pending tasks in a random order that is
+// the same for the same seed.
+func NewSeededStepExecutor(seed int64) *StepExecutor {
+	ret := NewStepExecutor()
+	ret.rand = rand.New(rand.NewSource(seed))
+	return ret
+}
+
+// NewOrderedStepExecutor returns a StepExecutor running the tasks in the order of the given task
+// names. Tasks without a name are named stage<index>-task<index>.
+func NewOrderedStepExecutor(names ...string) *StepExecutor {
+	ret := NewStepExecutor()
+	ret.order = names
+	return ret
+}
+
+func (s *StepExecutor) Execute(ctx context.Context, taskFunc koncurrent.TaskFunc, taskId int, resultChn chan koncurrent.TaskResult, opt koncurrent.TaskExecutionOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := opt.Name()
+	if len(name) == 0 {
+		name = fmt.Sprintf("stage%d-task%d", s.stage, taskId)
+	}
+	s.pending = append(s.pending, &stepTask{
+		ctx:       ctx,
+		taskFunc:  taskFunc,
+		taskId:    taskId,
+		resultChn: resultChn,
+		opt:       opt,
+		name:      name,
+	})
+	s.submitted++
+	s.changes++
+	s.changed.Broadcast()
+}
+
+// Step runs the next pending task. It returns false if there is no pending task, and an error if
+// the next task of an explicit order is not pending.
+func (s *StepExecutor) Step() (bool, error) {
+	s.mu.Lock()
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return false, nil
+	}
+	next := 0
+	switch {
+	case len(s.order) > 0:
+		next = -1
+		for i := range s.pending {
+			if s.pending[i].name == s.order[0] {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			err := fmt.Errorf("task %s is not pending, pending tasks are %v", s.order[0], s.pendingNames())
+			s.mu.Unlock()
+			return false, err
+		}
+		s.order = s.order[1:]
+	case s.rand != nil:
+		next = s.rand.Intn(len(s.pending))
+	}
+	task := s.pending[next]
+	s.pending = append(s.pending[:next], s.pending[next+1:]...)
+	s.trace = append(s.trace, task.name)
+	s.mu.Unlock()
+	koncurrent.ImmediateExecutor{}.Execute(task.ctx, task.taskFunc, task.taskId, task.resultChn, task.opt)
+	s.mu.Lock()
+	s.changed.Broadcast()
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Pending returns the names of the tasks waiting to be stepped.
+func (s *StepExecutor) Pending() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pendingNames()
+}
+
+// Trace returns the names of the stepped tasks in the order they ran.
+func (s *StepExecutor) Trace() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.trace...)
+}
+
+// Observe tracks the stages of the execution driven by Run.
+func (s *StepExecutor) Observe(event koncurrent.Event) {
+	if event.Kind != koncurrent.EventStageStart {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stage = event.Stage
+	s.stageTasks = event.Tasks
+	s.stageParallel = event.Parallel
+	s.submitted = 0
+	s.changes++
+}
+
+// Run awaits the execution and steps its tasks whenever Await is waiting for them, until Await
+// returns. All the tasks of the execution are expected to use this executor. In a parallel stage,
+// Run waits for all of its tasks to reach the executor before stepping them, or, when some never do
+// because they are skipped from a checkpoint, served from a cache, deduplicated or coalesced with
+// another, until no task arrived for a few milliseconds.
+func (s *StepExecutor) Run(ctx context.Context, execution koncurrent.Execution) (koncurrent.ExecutionResults, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var results koncurrent.ExecutionResults
+	var err error
+	done := false
+	go func() {
+		r, e := execution.Observe(s).Await(ctx)
+		s.mu.Lock()
+		results, err, done = r, e, true
+		s.changed.Broadcast()
+		s.mu.Unlock()
+	}()
+	for {
+		s.mu.Lock()
+		for !done && !s.awaiting() {
+			if len(s.pending) > 0 {
+				s.settleAfter(settleDelay)
+			}
+			s.changed.Wait()
+		}
+		if done {
+			if s.timer != nil {
+				s.timer.Stop()
+			}
+			s.mu.Unlock()
+			return results, err
+		}
+		s.mu.Unlock()
+		if _, stepErr := s.Step(); stepErr != nil {
+			cancel()
+			s.mu.Lock()
+			for !done {
+				s.changed.Wait()
+			}
+			s.mu.Unlock()
+			return results, stepErr
+		}
+	}
+}
+
+func (s *StepExecutor) awaiting() bool {
+	if len(s.pending) == 0 {
+		return false
+	}
+	return !s.stageParallel || s.submitted == s.stageTasks || s.settled == s.changes
+}
+
+// settleAfter marks the executor settled once d passes without a change.
+func (s *StepExecutor) settleAfter(d time.Duration) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	changes := s.changes
+	s.timer = time.AfterFunc(d, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.changes == changes {
+			s.settled = changes
+			s.changed.Broadcast()
+		}
+	})
+}
+
+func (s *StepExecutor) pendingNames() []string {
+	ret := make([]string, len(s.pending))
+	for i := range s.pending {
+		ret[i] = s.pending[i].name
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+package koncurrenttest
+
+import (
+	"context"
+	"errors"
+	"github.com/raymond852/koncurrent/v3"
+	"testing"
+)
+
+func newTasks(s *StepExecutor, ran *[]string, names ...string) []koncurrent.TaskExecution {
+	ret := make([]koncurrent.TaskExecution, len(names))
+	for i := range names {
+		name := names[i]
+		var task koncurrent.TaskFunc = func(ctx context.Context) error {
+			*ran = append(*ran, name)
+			if name == "fail" {
+				return errors.New("test")
+			}
+			return nil
+		}
+		ret[i] = task.Executor(s).Name(name)
+	}
+	return ret
+}
+
+func TestStepExecutor_Run(t *testing.T) {
+	var ran []string
+	underTest := NewOrderedStepExecutor("c", "a", "b", "d", "e")
+	tasks := newTasks(underTest, &ran, "a", "b", "c", "d", "e")
+	results, err := underTest.Run(context.Background(), koncurrent.ExecuteParallel(tasks[:3]...).ExecuteSerial(tasks[3:]...))
+	assertNil(t, err)
+	assertEqual(t, 2, len(results))
+	AssertOrder(t, underTest.Trace(), "c", "a", "b", "d", "e")
+	AssertOrder(t, ran, "c", "a", "b", "d", "e")
+	AssertBefore(t, ran, "b", "d")
+	assertEqual(t, 0, len(underTest.Pending()))
+}
+
+func TestStepExecutor_RunOrderNotPending(t *testing.T) {
+	var ran []string
+	underTest := NewOrderedStepExecutor("a", "c")
+	tasks := newTasks(underTest, &ran, "a", "b", "c")
+	_, err := underTest.Run(context.Background(), koncurrent.ExecuteSerial(tasks...))
+	assertNotNil(t, err)
+	assertEqual(t, "task c is not pending, pending tasks are [b]", err.Error())
+	AssertOrder(t, ran, "a")
+}
+
+func TestStepExecutor_RunCheckpoint(t *testing.T) {
+	var ran []string
+	underTest := NewOrderedStepExecutor("c", "b", "d")
+	tasks := newTasks(underTest, &ran, "a", "b", "c", "d")
+	store := koncurrent.NewMemoryCheckpointStore()
+	assertNil(t, store.Save("nightly", "a"))
+	// the task skipped from the checkpoint never reaches the executor
+	results, err := underTest.Run(context.Background(), koncurrent.ExecuteParallel(tasks[:3]...).ExecuteSerial(tasks[3]).Checkpoint(store, "nightly"))
+	assertNil(t, err)
+	assertEqual(t, 2, len(results))
+	AssertOrder(t, ran, "c", "b", "d")
+}
+
+func TestStepExecutor_Seeded(t *testing.T) {
+	var traces [][]string
+	for i := 0; i < 2; i++ {
+		var ran []string
+		underTest := NewSeededStepExecutor(42)
+		tasks := newTasks(underTest, &ran, "a", "b", "c", "d", "e", "fail")
+		_, err := underTest.Run(context.Background(), koncurrent.ExecuteParallel(tasks...))
+		assertNotNil(t, err)
+		traces = append(traces, underTest.Trace())
+	}
+	AssertOrder(t, traces[0], traces[1]...)
+}
+
+func TestStepExecutor_Step(t *testing.T) {
+	underTest := NewStepExecutor()
+	resultChn := make(chan koncurrent.TaskResult, 2)
+	underTest.Execute(context.Background(), func(ctx context.Context) error {
+		return nil
+	}, 0, resultChn, koncurrent.TaskExecutionOptions{})
+	underTest.Execute(context.Background(), func(ctx context.Context) error {
+		panic("test panic")
+	}, 1, resultChn, koncurrent.TaskExecutionOptions{})
+	AssertOrder(t, underTest.Pending(), "stage0-task0", "stage0-task1")
+	for i := 0; i < 2; i++ {
+		ok, err := underTest.Step()
+		assertTrue(t, ok)
+		assertNil(t, err)
+	}
+	ok, _ := underTest.Step()
+	assertTrue(t, !ok)
+	assertEqual(t, 2, len(resultChn))
+}
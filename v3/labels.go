@@ -0,0 +1,57 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+)
+
+// labelKey is the pprof label set on every goroutine started by the library, which is how the
+// leak checker of koncurrenttest tells them apart.
+const labelKey = "koncurrent"
+
+var (
+	poolWorkerLabels = goroutineLabels("pool-worker")
+	asyncTaskLabels  = goroutineLabels("async-task")
+	executionLabels  = goroutineLabels("execution")
+	hedgeLabels      = goroutineLabels("hedge")
+	coalesceLabels   = goroutineLabels("coalesce")
+	batchLabels      = goroutineLabels("batch")
+	schedulerLabels  = goroutineLabels("scheduler")
+	timeoutLabels    = goroutineLabels("timeout")
+	breakerLabels    = goroutineLabels("circuit-breaker")
+)
+
+func goroutineLabels(kind string) context.Context {
+	return pprof.WithLabels(context.Background(), pprof.Labels(labelKey, kind))
+}
+
+// taskLabels returns the profiler labels of a task run by the given kind of executor, or nil if
+// neither the execution nor the executor asked for them.
+func taskLabels(enabled bool, opt TaskExecutionOptions, taskId int, executor string, pool string) []string {
+	if !enabled && !opt.profilerLabels {
+		return nil
+	}
+	labels := []string{"executor", executor, "stage", strconv.Itoa(opt.stage)}
+	if pool != "" {
+		labels = append(labels, "pool", pool)
+	}
+	if opt.execution != "" {
+		labels = append(labels, "execution", opt.execution)
+	}
+	if opt.name != "" {
+		labels = append(labels, "task", opt.name)
+	} else {
+		labels = append(labels, "task", strconv.Itoa(taskId))
+	}
+	return labels
+}
+
+// runLabelled runs the task with its labels set on the goroutine, like pprof.Do, then sets the
+// labels of restore back on the goroutine.
+func runLabelled(ctx context.Context, taskFunc TaskFunc, labels []string, restore context.Context) error {
+	labelCtx := pprof.WithLabels(ctx, pprof.Labels(labels...))
+	pprof.SetGoroutineLabels(labelCtx)
+	defer pprof.SetGoroutineLabels(restore)
+	return taskFunc(labelCtx)
+}
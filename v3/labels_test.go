@@ -0,0 +1,77 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+)
+
+func taskLabelsOf(ctx context.Context) map[string]string {
+	labels := map[string]string{}
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		labels[key] = value
+		return true
+	})
+	return labels
+}
+
+func TestExecution_ProfilerLabels(t *testing.T) {
+	pe := NewPoolExecutor(2, 2)
+	defer pe.Close()
+	var poolLabels, immediateLabels map[string]string
+	var poolTask TaskFunc = func(ctx context.Context) error {
+		poolLabels = taskLabelsOf(ctx)
+		return nil
+	}
+	var immediateTask TaskFunc = func(ctx context.Context) error {
+		immediateLabels = taskLabelsOf(ctx)
+		return nil
+	}
+	_, err := ExecuteSerial(immediateTask.Immediate()).
+		ExecuteParallel(poolTask.Pool(pe.Name("orders")).Name("load")).
+		Name("checkout").
+		ProfilerLabels().
+		Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, "pool", poolLabels["executor"])
+	assertEqual(t, "orders", poolLabels["pool"])
+	assertEqual(t, "checkout", poolLabels["execution"])
+	assertEqual(t, "1", poolLabels["stage"])
+	assertEqual(t, "load", poolLabels["task"])
+	assertEqual(t, "pool-worker", poolLabels[labelKey])
+	assertEqual(t, "immediate", immediateLabels["executor"])
+	assertEqual(t, "0", immediateLabels["stage"])
+	assertEqual(t, "0", immediateLabels["task"])
+}
+
+func TestPoolExecutor_ProfilerLabels(t *testing.T) {
+	pe := NewPoolExecutor(1, 1)
+	defer pe.Close()
+	var labels map[string]string
+	var task TaskFunc = func(ctx context.Context) error {
+		labels = taskLabelsOf(ctx)
+		return nil
+	}
+	_, err := task.Pool(pe).Execution().Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 0, len(labels))
+
+	_, err = task.Pool(pe.Name("reports").ProfilerLabels()).Execution().Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, "pool", labels["executor"])
+	assertEqual(t, "reports", labels["pool"])
+}
+
+func TestWorkStealingPoolExecutor_ProfilerLabels(t *testing.T) {
+	pe := NewWorkStealingPoolExecutor(1).ProfilerLabels()
+	defer pe.Close()
+	var labels map[string]string
+	var task TaskFunc = func(ctx context.Context) error {
+		labels = taskLabelsOf(ctx)
+		return nil
+	}
+	_, err := task.Executor(pe).Name("index").Execution().Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, "work-stealing", labels["executor"])
+	assertEqual(t, "index", labels["task"])
+}
@@ -0,0 +1,144 @@
+package koncurrent
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// LatencyReport breaks the duration of an Await down by stage and task.
+type LatencyReport struct {
+	// Total is the time from the start of the first stage to the end of the last one.
+	Total time.Duration
+	// Queued and Running are the time the tasks spent waiting for their executor, for example in
+	// the queue of a PoolExecutor, and the time they spent running, summed over all the tasks.
+	Queued  time.Duration
+	Running time.Duration
+	Stages  []StageLatency
+	// CriticalPath is the tasks that determined Total: every task of the serial stages and the
+	// slowest task of the parallel ones.
+	CriticalPath []TaskLatency
+}
+
+type StageLatency struct {
+	Stage    int
+	Parallel bool
+	Duration time.Duration
+	// Savings is how much sooner a parallel stage would have finished without its slowest task.
+	Savings time.Duration
+	Tasks   []TaskLatency
+}
+
+type TaskLatency struct {
+	Stage   int
+	Task    int
+	Name    string
+	Queued  time.Duration
+	Running time.Duration
+	// Slack is how much longer the task of a parallel stage could have taken without delaying the
+	// stage.
+	Slack    time.Duration
+	Critical bool
+}
+
+func (t TaskLatency) Total() time.Duration {
+	return t.Queued + t.Running
+}
+
+// QueuedShare is the share of the time of the tasks spent waiting for their executor.
+func (r LatencyReport) QueuedShare() float64 {
+	if r.Queued+r.Running == 0 {
+		return 0
+	}
+	return float64(r.Queued) / float64(r.Queued+r.Running)
+}
+
+// Analyze computes the latency report of the recorded Await. The tasks that did not finish, because
+// the context of the Await was done, are left out.
+func (r *Recorder) Analyze() LatencyReport {
+	var report LatencyReport
+	records := r.Records()
+	stages := r.Stages()
+	for _, stage := range stages {
+		latency := StageLatency{
+			Stage:    stage.Stage,
+			Parallel: stage.Parallel,
+		}
+		if !stage.Finished.IsZero() {
+			latency.Duration = stage.Finished.Sub(stage.Started)
+			report.Total += latency.Duration
+		}
+		var slowest, second time.Duration
+		critical := -1
+		for _, record := range records {
+			if record.Stage != stage.Stage || record.Finished.IsZero() {
+				continue
+			}
+			task := TaskLatency{
+				Stage: record.Stage,
+				Task:  record.Task,
+				Name:  taskName(record.Stage, record.Task, record.Name),
+			}
+			if record.Started.IsZero() {
+				// served from a cache without running
+				task.Queued = record.Finished.Sub(record.Submitted)
+			} else {
+				task.Queued = record.Started.Sub(record.Submitted)
+				task.Running = record.Finished.Sub(record.Started)
+			}
+			report.Queued += task.Queued
+			report.Running += task.Running
+			switch total := task.Total(); {
+			case total > slowest:
+				slowest, second = total, slowest
+				critical = len(latency.Tasks)
+			case total > second:
+				second = total
+			}
+			task.Critical = !stage.Parallel
+			latency.Tasks = append(latency.Tasks, task)
+		}
+		if stage.Parallel && critical >= 0 {
+			latency.Tasks[critical].Critical = true
+			latency.Savings = slowest - second
+			for i := range latency.Tasks {
+				latency.Tasks[i].Slack = slowest - latency.Tasks[i].Total()
+			}
+		}
+		for _, task := range latency.Tasks {
+			if task.Critical {
+				report.CriticalPath = append(report.CriticalPath, task)
+			}
+		}
+		report.Stages = append(report.Stages, latency)
+	}
+	return report
+}
+
+// Table formats the report as a table for logs, with a line per task followed by the totals.
+func (r LatencyReport) Table() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tTASK\tQUEUED\tRUNNING\tSLACK\tCRITICAL")
+	for _, stage := range r.Stages {
+		for _, task := range stage.Tasks {
+			critical := ""
+			if task.Critical {
+				critical = "*"
+			}
+			slack := "-"
+			if stage.Parallel {
+				slack = task.Slack.String()
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", task.Stage, task.Name, task.Queued, task.Running, slack, critical)
+		}
+	}
+	w.Flush()
+	path := make([]string, len(r.CriticalPath))
+	for i, task := range r.CriticalPath {
+		path[i] = task.Name
+	}
+	fmt.Fprintf(&sb, "total %s, queued %.0f%%, critical path %s\n", r.Total, r.QueuedShare()*100, strings.Join(path, " -> "))
+	return sb.String()
+}
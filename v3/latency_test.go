@@ -0,0 +1,58 @@
+package koncurrent
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Analyze(t *testing.T) {
+	start := time.Now()
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+	recorder := NewRecorder()
+	for _, event := range []Event{
+		{Kind: EventStageStart, Stage: 0, Tasks: 2, Parallel: true, Time: at(0)},
+		{Kind: EventTaskSubmit, Stage: 0, Task: 0, Name: "price", Time: at(0)},
+		{Kind: EventTaskSubmit, Stage: 0, Task: 1, Name: "stock", Time: at(0)},
+		{Kind: EventTaskStart, Stage: 0, Task: 0, Name: "price", Attempt: 1, Time: at(1)},
+		{Kind: EventTaskStart, Stage: 0, Task: 1, Name: "stock", Attempt: 1, Time: at(5)},
+		{Kind: EventTaskResult, Stage: 0, Task: 0, Name: "price", Time: at(11)},
+		{Kind: EventTaskResult, Stage: 0, Task: 1, Name: "stock", Time: at(25)},
+		{Kind: EventStageFinish, Stage: 0, Tasks: 2, Parallel: true, Time: at(25)},
+		{Kind: EventStageStart, Stage: 1, Tasks: 1, Time: at(25)},
+		{Kind: EventTaskSubmit, Stage: 1, Task: 0, Time: at(25)},
+		{Kind: EventTaskStart, Stage: 1, Task: 0, Attempt: 1, Time: at(25)},
+		{Kind: EventTaskResult, Stage: 1, Task: 0, Time: at(30)},
+		{Kind: EventStageFinish, Stage: 1, Tasks: 1, Time: at(30)},
+	} {
+		recorder.Observe(event)
+	}
+	report := recorder.Analyze()
+	assertEqual(t, 30*time.Millisecond, report.Total)
+	assertEqual(t, 6*time.Millisecond, report.Queued)
+	assertEqual(t, 35*time.Millisecond, report.Running)
+	assertEqual(t, 2, len(report.Stages))
+	stage := report.Stages[0]
+	assertEqual(t, 25*time.Millisecond, stage.Duration)
+	assertEqual(t, 14*time.Millisecond, stage.Savings)
+	assertEqual(t, 14*time.Millisecond, stage.Tasks[0].Slack)
+	assertEqual(t, 11*time.Millisecond, stage.Tasks[0].Total())
+	assertTrue(t, !stage.Tasks[0].Critical)
+	assertEqual(t, time.Duration(0), stage.Tasks[1].Slack)
+	assertTrue(t, stage.Tasks[1].Critical)
+	assertEqual(t, 2, len(report.CriticalPath))
+	assertEqual(t, "stock", report.CriticalPath[0].Name)
+	assertEqual(t, "stage1-task0", report.CriticalPath[1].Name)
+
+	table := report.Table()
+	for _, s := range []string{
+		"STAGE  TASK          QUEUED  RUNNING  SLACK  CRITICAL",
+		"0      price         1ms     10ms     14ms",
+		"1      stage1-task0  0s      5ms      -      *",
+		"total 30ms, queued 15%, critical path stock -> stage1-task0",
+	} {
+		assertTrue(t, strings.Contains(table, s))
+	}
+}
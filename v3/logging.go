@@ -0,0 +1,140 @@
+package koncurrent
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// LogLevels are the levels of the records logged for each kind of event.
+type LogLevels struct {
+	Start   slog.Level
+	Finish  slog.Level
+	Failure slog.Level
+	Panic   slog.Level
+	// Abort is the level of the record logged when a stage fails or its context is done, which stops
+	// the execution.
+	Abort slog.Level
+}
+
+var DefaultLogLevels = LogLevels{
+	Start:   slog.LevelDebug,
+	Finish:  slog.LevelDebug,
+	Failure: slog.LevelWarn,
+	Panic:   slog.LevelError,
+	Abort:   slog.LevelError,
+}
+
+type taskLogger struct {
+	logger *slog.Logger
+	levels LogLevels
+}
+
+// Logger logs the start, finish, failure and panic of the tasks of the execution, and the abort of
+// its stages, to logger at the given levels. The records carry the execution, stage and task names.
+func (e Execution) Logger(logger *slog.Logger, levels LogLevels) Execution {
+	ret := e
+	ret.logger = &taskLogger{
+		logger: logger,
+		levels: levels,
+	}
+	return ret
+}
+
+// LoggingExecutor logs the tasks run on its executor like Execution.Logger does, except for the
+// tasks of an execution that has a logger of its own.
+type LoggingExecutor struct {
+	executor TaskExecutor
+	logger   *taskLogger
+}
+
+func NewLoggingExecutor(executor TaskExecutor, logger *slog.Logger, levels LogLevels) LoggingExecutor {
+	return LoggingExecutor{
+		executor: executor,
+		logger: &taskLogger{
+			logger: logger,
+			levels: levels,
+		},
+	}
+}
+
+func (l LoggingExecutor) Execute(ctx context.Context, taskFunc TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	if opt.logger != nil {
+		l.executor.Execute(ctx, taskFunc, taskId, resultChn, opt)
+		return
+	}
+	opt.logger = l.logger
+	l.executor.Execute(ctx, loggedTaskFunc(taskFunc, taskId, opt), taskId, resultChn, opt)
+}
+
+// log logs the record of a task event. Callers check that the logger is enabled at level first,
+// so that a disabled level costs no more than that check.
+func (l *taskLogger) log(ctx context.Context, level slog.Level, msg string, opt TaskExecutionOptions, attrs ...slog.Attr) {
+	record := make([]slog.Attr, 0, len(attrs)+2)
+	if opt.execution != "" {
+		record = append(record, slog.String("execution", opt.execution))
+	}
+	record = append(record, slog.Int("stage", opt.stage))
+	record = append(record, attrs...)
+	l.logger.LogAttrs(ctx, level, msg, record...)
+}
+
+func (l *taskLogger) enabled(ctx context.Context, level slog.Level) bool {
+	return l.logger.Enabled(ctx, level)
+}
+
+func loggedTaskFunc(taskFunc TaskFunc, taskId int, opt TaskExecutionOptions) TaskFunc {
+	l := opt.logger
+	levels := l.levels
+	return func(ctx context.Context) (err error) {
+		start := time.Now()
+		if l.enabled(ctx, levels.Start) {
+			l.log(ctx, levels.Start, "task started", opt, slog.String("task", taskName(opt.stage, taskId, opt.name)))
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				// the stack is taken before the deferred function returns, while it still has the
+				// frames of the panic
+				if l.enabled(ctx, levels.Panic) {
+					l.log(ctx, levels.Panic, "task panicked", opt,
+						slog.String("task", taskName(opt.stage, taskId, opt.name)),
+						slog.Duration("duration", time.Since(start)),
+						slog.Any("panic", r),
+						slog.String("stack", string(debug.Stack())))
+				}
+				panic(r)
+			}
+		}()
+		err = taskFunc(ctx)
+		if err != nil {
+			if l.enabled(ctx, levels.Failure) {
+				l.log(ctx, levels.Failure, "task failed", opt,
+					slog.String("task", taskName(opt.stage, taskId, opt.name)),
+					slog.Duration("duration", time.Since(start)),
+					slog.Any("error", err))
+			}
+			return err
+		}
+		if l.enabled(ctx, levels.Finish) {
+			l.log(ctx, levels.Finish, "task finished", opt,
+				slog.String("task", taskName(opt.stage, taskId, opt.name)),
+				slog.Duration("duration", time.Since(start)))
+		}
+		return nil
+	}
+}
+
+func (e Execution) logAbort(ctx context.Context, stage int, started time.Time, err error) {
+	if !e.logger.enabled(ctx, e.logger.levels.Abort) {
+		return
+	}
+	opt := TaskExecutionOptions{
+		stage:     stage,
+		execution: e.name,
+	}
+	e.logger.log(ctx, e.logger.levels.Abort, "stage aborted", opt,
+		slog.Int("tasks", len(e.tasksList[stage])),
+		slog.Duration("duration", time.Since(started)),
+		slog.Any("error", err))
+}
@@ -0,0 +1,148 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingHandler struct {
+	mu      sync.Mutex
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record.Clone())
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) WithGroup(name string) slog.Handler {
+	return h
+}
+
+// logged returns the messages and the attributes of the records, attributes keyed by the message
+// and the task or stage they are about.
+func (h *recordingHandler) logged() map[string]map[string]slog.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ret := map[string]map[string]slog.Value{}
+	for _, record := range h.records {
+		attrs := map[string]slog.Value{"level": slog.StringValue(record.Level.String())}
+		record.Attrs(func(attr slog.Attr) bool {
+			attrs[attr.Key] = attr.Value
+			return true
+		})
+		key := record.Message
+		if task, ok := attrs["task"]; ok {
+			key += " " + task.String()
+		} else {
+			key += " " + attrs["stage"].String()
+		}
+		ret[key] = attrs
+	}
+	return ret
+}
+
+func TestExecution_Logger(t *testing.T) {
+	handler := &recordingHandler{level: slog.LevelDebug}
+	var ok TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var failing TaskFunc = func(ctx context.Context) error {
+		return errors.New("out of stock")
+	}
+	var panicking TaskFunc = func(ctx context.Context) error {
+		panic("nil basket")
+	}
+	_, err := ExecuteParallel(ok.Immediate().Name("auth"), failing.Async().Name("stock")).
+		ExecuteSerial(panicking.Immediate().Name("render")).
+		Name("checkout").
+		Logger(slog.New(handler), DefaultLogLevels).
+		Await(context.Background())
+	assertNotNil(t, err)
+
+	logged := handler.logged()
+	assertEqual(t, 5, len(logged))
+	assertEqual(t, "DEBUG", logged["task started auth"]["level"].String())
+	assertEqual(t, "checkout", logged["task started auth"]["execution"].String())
+	assertEqual(t, int64(0), logged["task started auth"]["stage"].Int64())
+	assertEqual(t, "DEBUG", logged["task finished auth"]["level"].String())
+	assertTrue(t, logged["task finished auth"]["duration"].Duration() >= 0)
+	assertEqual(t, "WARN", logged["task failed stock"]["level"].String())
+	assertEqual(t, "out of stock", logged["task failed stock"]["error"].String())
+	assertEqual(t, "ERROR", logged["stage aborted 0"]["level"].String())
+	assertEqual(t, int64(2), logged["stage aborted 0"]["tasks"].Int64())
+	assertEqual(t, "out of stock", logged["stage aborted 0"]["error"].String())
+	_, ran := logged["task started render"]
+	assertTrue(t, !ran)
+}
+
+func TestExecution_LoggerPanic(t *testing.T) {
+	handler := &recordingHandler{level: slog.LevelError}
+	var panicking TaskFunc = func(ctx context.Context) error {
+		panic("nil basket")
+	}
+	_, err := ExecuteSerial(panicking.Immediate().Name("render")).
+		Logger(slog.New(handler), DefaultLogLevels).
+		Await(context.Background())
+	_, ok := err.(PanicError)
+	assertTrue(t, ok)
+
+	// the start of the task is below the level of the handler
+	logged := handler.logged()
+	assertEqual(t, 2, len(logged))
+	assertEqual(t, "nil basket", logged["task panicked render"]["panic"].String())
+	assertTrue(t, strings.Contains(logged["task panicked render"]["stack"].String(), "TestExecution_LoggerPanic"))
+	assertEqual(t, "ERROR", logged["stage aborted 0"]["level"].String())
+}
+
+func TestLoggingExecutor(t *testing.T) {
+	handler := &recordingHandler{level: slog.LevelDebug}
+	executor := NewLoggingExecutor(ImmediateExecutor{}, slog.New(handler), LogLevels{
+		Start:  slog.LevelDebug - 1,
+		Finish: slog.LevelInfo,
+	})
+	var ok TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	_, err := ExecuteSerial(ok.Executor(executor).Name("auth")).Await(context.Background())
+	assertNil(t, err)
+	logged := handler.logged()
+	assertEqual(t, 1, len(logged))
+	assertEqual(t, "INFO", logged["task finished auth"]["level"].String())
+
+	// an execution with a logger of its own logs its tasks once
+	executionHandler := &recordingHandler{level: slog.LevelDebug}
+	_, err = ExecuteSerial(ok.Executor(executor).Name("price")).
+		Logger(slog.New(executionHandler), DefaultLogLevels).
+		Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 1, len(handler.logged()))
+	assertEqual(t, 2, len(executionHandler.logged()))
+}
+
+func BenchmarkExecution_LoggerDisabled(b *testing.B) {
+	logger := slog.New(&recordingHandler{level: slog.LevelError})
+	var ok TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	execution := ExecuteSerial(ok.Immediate()).Logger(logger, DefaultLogLevels)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		execution.Await(context.Background())
+	}
+}
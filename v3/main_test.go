@@ -0,0 +1,24 @@
+package koncurrent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raymond852/koncurrent/v3/internal/leakcheck"
+)
+
+// TestMain fails the suite if the tests leave pool workers or task goroutines behind.
+func TestMain(m *testing.M) {
+	snapshot := leakcheck.Take()
+	code := m.Run()
+	if code == 0 {
+		if leaks := snapshot.Leaks(5 * time.Second); len(leaks) > 0 {
+			fmt.Fprintf(os.Stderr, "found leaked goroutines:\n%s\n", strings.Join(leaks, "\n\n"))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
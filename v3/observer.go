@@ -0,0 +1,159 @@
+package koncurrent
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+type EventKind int
+
+const (
+	// EventTaskSubmit is emitted when Await hands a task to its executor.
+	EventTaskSubmit EventKind = iota
+	// EventTaskStart is emitted when an attempt of the task function starts running.
+	EventTaskStart
+	// EventTaskFinish is emitted when an attempt of the task function returns or panics.
+	EventTaskFinish
+	// EventTaskResult is emitted when Await receives the outcome of a task.
+	EventTaskResult
+	// EventTaskHedge is emitted when a hedged attempt of a task is launched.
+	EventTaskHedge
+	// EventCacheHit is emitted when the outcome of a cached task is served from its cache.
+	EventCacheHit
+	// EventCacheMiss is emitted when a cached task has to run because its key is not cached.
+	EventCacheMiss
+	// EventStageStart is emitted before Await submits the tasks of a stage.
+	EventStageStart
+	// EventStageFinish is emitted when a stage completed, failed or its context is done.
+	EventStageFinish
+	// EventTaskDeduplicated is emitted when a task is skipped because a task with its idempotency
+	// key already succeeded.
+	EventTaskDeduplicated
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventTaskSubmit:
+		return "task_submit"
+	case EventTaskStart:
+		return "task_start"
+	case EventTaskFinish:
+		return "task_finish"
+	case EventTaskResult:
+		return "task_result"
+	case EventTaskHedge:
+		return "task_hedge"
+	case EventCacheHit:
+		return "cache_hit"
+	case EventCacheMiss:
+		return "cache_miss"
+	case EventStageStart:
+		return "stage_start"
+	case EventStageFinish:
+		return "stage_finish"
+	case EventTaskDeduplicated:
+		return "task_deduplicated"
+	default:
+		return "unknown"
+	}
+}
+
+type Event struct {
+	Kind  EventKind
+	Stage int
+	// Tasks and Parallel describe the stage of stage events.
+	Tasks    int
+	Parallel bool
+	Task     int
+	Name     string
+	Attempt  int
+	// Goroutine is the id of the goroutine running the attempt of task start and finish events.
+	Goroutine uint64
+	Time      time.Time
+	Err       error
+}
+
+// Observer receives the events of an Execution. Observe is called from the goroutines running the
+// tasks, so implementations must be safe for concurrent use.
+type Observer interface {
+	Observe(event Event)
+}
+
+type ObserverFunc func(event Event)
+
+func (f ObserverFunc) Observe(event Event) {
+	f(event)
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) Observe(event Event) {
+	for i := range m {
+		m[i].Observe(event)
+	}
+}
+
+func (e Execution) Observe(observer Observer) Execution {
+	ret := e
+	switch o := e.observer.(type) {
+	case nil:
+		ret.observer = observer
+	case multiObserver:
+		ret.observer = append(append(multiObserver{}, o...), observer)
+	default:
+		ret.observer = multiObserver{o, observer}
+	}
+	return ret
+}
+
+func (opt TaskExecutionOptions) emit(kind EventKind, taskId int, attempt int, err error) {
+	opt.emitOn(0, kind, taskId, attempt, err)
+}
+
+func (opt TaskExecutionOptions) emitOn(goroutine uint64, kind EventKind, taskId int, attempt int, err error) {
+	opt.observer.Observe(Event{
+		Kind:      kind,
+		Stage:     opt.stage,
+		Task:      taskId,
+		Name:      opt.name,
+		Attempt:   attempt,
+		Goroutine: goroutine,
+		Time:      time.Now(),
+		Err:       err,
+	})
+}
+
+func observedTaskFunc(taskFunc TaskFunc, taskId int, opt TaskExecutionOptions) TaskFunc {
+	var attempts int32
+	return func(ctx context.Context) (err error) {
+		attempt := int(atomic.AddInt32(&attempts, 1))
+		goroutine := goroutineID()
+		opt.emitOn(goroutine, EventTaskStart, taskId, attempt, nil)
+		defer func() {
+			if r := recover(); r != nil {
+				opt.emitOn(goroutine, EventTaskFinish, taskId, attempt, PanicError{})
+				panic(r)
+			}
+		}()
+		err = taskFunc(ctx)
+		opt.emitOn(goroutine, EventTaskFinish, taskId, attempt, err)
+		return err
+	}
+}
+
+// goroutineID parses the id of the calling goroutine out of the "goroutine 42 [running]:" header
+// of its stack trace.
+func goroutineID() uint64 {
+	var buf [32]byte
+	n := runtime.Stack(buf[:], false)
+	header := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i > 0 {
+		header = header[:i]
+	}
+	id, _ := strconv.ParseUint(string(header), 10, 64)
+	return id
+}
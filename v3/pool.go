@@ -2,12 +2,44 @@ package koncurrent
 
 import (
 	"context"
+	"expvar"
 	"github.com/opentracing/opentracing-go"
 	"runtime/debug"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
 )
 
 type PoolExecutor struct {
-	queue chan taskContext
+	queue          chan taskContext
+	stats          *poolStats
+	name           string
+	profilerLabels bool
+}
+
+type poolStats struct {
+	workers   int
+	busy      int32
+	completed uint64
+	failed    uint64
+	panicked  uint64
+	latency   movingAverage
+}
+
+// PoolStats is the occupancy of a pool at some point in time, and the outcome of the tasks it ran.
+type PoolStats struct {
+	Workers int `json:"workers"`
+	// Busy is the number of workers running a task.
+	Busy          int `json:"busy"`
+	Queued        int `json:"queued"`
+	QueueCapacity int `json:"queueCapacity"`
+	// Completed is the number of tasks run, Failed and Panicked the number of those that returned an
+	// error and that panicked.
+	Completed uint64 `json:"completed"`
+	Failed    uint64 `json:"failed"`
+	Panicked  uint64 `json:"panicked"`
+	// Latency is the moving average of the time the tasks took to run.
+	Latency time.Duration `json:"latency"`
 }
 
 type taskContext struct {
@@ -16,6 +48,7 @@ type taskContext struct {
 	task      TaskFunc
 	taskId    int
 	resultChn chan TaskResult
+	labels    []string
 }
 
 func (p PoolExecutor) Execute(ctx context.Context, task TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
@@ -25,51 +58,136 @@ func (p PoolExecutor) Execute(ctx context.Context, task TaskFunc, taskId int, re
 		taskId:    taskId,
 		resultChn: resultChn,
 		opt:       opt,
+		labels:    taskLabels(p.profilerLabels, opt, taskId, "pool", p.name),
+	}
+}
+
+// Name returns a copy of the pool, sharing its workers, that is reported under the given name.
+func (p PoolExecutor) Name(name string) PoolExecutor {
+	ret := p
+	ret.name = name
+	return ret
+}
+
+// ProfilerLabels returns a copy of the pool, sharing its workers, that runs every task with pprof
+// labels for the executor, pool, execution, stage and task, whether or not its execution asked for
+// them.
+func (p PoolExecutor) ProfilerLabels() PoolExecutor {
+	ret := p
+	ret.profilerLabels = true
+	return ret
+}
+
+// Stats returns the stats of the pool, which are all zero for a PoolExecutor that was not created by
+// NewPoolExecutor. A task is accounted for by the time its result is received.
+func (p PoolExecutor) Stats() PoolStats {
+	if p.stats == nil {
+		return PoolStats{}
+	}
+	return PoolStats{
+		Workers:       p.stats.workers,
+		Busy:          int(atomic.LoadInt32(&p.stats.busy)),
+		Queued:        len(p.queue),
+		QueueCapacity: cap(p.queue),
+		Completed:     atomic.LoadUint64(&p.stats.completed),
+		Failed:        atomic.LoadUint64(&p.stats.failed),
+		Panicked:      atomic.LoadUint64(&p.stats.panicked),
+		Latency:       p.stats.latency.value(),
 	}
 }
 
+// PublishStats publishes the stats of the pool as the expvar variable with the given name. Like
+// expvar.Publish, it panics if the name is already in use.
+func (p PoolExecutor) PublishStats(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return p.Stats()
+	}))
+}
+
+// Close stops the workers of the pool once the queued tasks have run. Tasks must not be submitted
+// to a closed pool.
+func (p PoolExecutor) Close() {
+	close(p.queue)
+}
+
 func NewPoolExecutor(poolSize int, queueSize int) PoolExecutor {
 	ret := PoolExecutor{
 		queue: make(chan taskContext, queueSize),
+		stats: &poolStats{workers: poolSize},
 	}
 	for i := 0; i < poolSize; i++ {
 		go func() {
-			for {
-				taskCtx := <-ret.queue
-				ctx := taskCtx.Context
-				tracingSpanName := taskCtx.opt.tracingSpanName
-				resultChn := taskCtx.resultChn
-				taskFunc := taskCtx.task
-				taskId := taskCtx.taskId
-				func() {
-					defer func() {
-						if r := recover(); r != nil {
-							resultChn <- TaskResult{
-								err: PanicError{
-									Stack: debug.Stack(),
-								},
-								id: taskId,
-							}
-						}
-					}()
-					var s opentracing.Span
-					c := ctx
-					if len(tracingSpanName) > 0 {
-						span, spanCtx := opentracing.StartSpanFromContext(ctx, tracingSpanName)
-						s = span
-						c = spanCtx
-					}
-					taskErr := taskFunc(c)
-					resultChn <- TaskResult{
-						err: taskErr,
-						id:  taskId,
-					}
-					if s != nil {
-						s.Finish()
-					}
-				}()
+			pprof.SetGoroutineLabels(poolWorkerLabels)
+			for taskCtx := range ret.queue {
+				runTaskContext(taskCtx, ret.stats)
 			}
 		}()
 	}
 	return ret
 }
+
+// start counts a worker starting a task, and record counts it finishing with its outcome. Both do
+// nothing on nil stats.
+func (s *poolStats) start() {
+	if s != nil {
+		atomic.AddInt32(&s.busy, 1)
+	}
+}
+
+func (s *poolStats) record(latency time.Duration, err error, panicked bool) {
+	if s == nil {
+		return
+	}
+	atomic.AddInt32(&s.busy, -1)
+	atomic.AddUint64(&s.completed, 1)
+	if panicked {
+		atomic.AddUint64(&s.panicked, 1)
+	} else if err != nil {
+		atomic.AddUint64(&s.failed, 1)
+	}
+	s.latency.observe(latency)
+}
+
+// runTaskContext runs the task, records its outcome in the stats, which may be nil, and then sends
+// it, so that the stats are up to date once the result is received.
+func runTaskContext(taskCtx taskContext, stats *poolStats) {
+	ctx := taskCtx.Context
+	tracingSpanName := taskCtx.opt.tracingSpanName
+	resultChn := taskCtx.resultChn
+	taskFunc := taskCtx.task
+	taskId := taskCtx.taskId
+	stats.start()
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			stats.record(time.Since(start), nil, true)
+			resultChn <- TaskResult{
+				err: PanicError{
+					Stack: debug.Stack(),
+				},
+				id: taskId,
+			}
+		}
+	}()
+	var s opentracing.Span
+	c := ctx
+	if len(tracingSpanName) > 0 {
+		span, spanCtx := opentracing.StartSpanFromContext(ctx, tracingSpanName)
+		s = span
+		c = spanCtx
+	}
+	var taskErr error
+	if taskCtx.labels != nil {
+		taskErr = runLabelled(c, taskFunc, append(taskCtx.labels, labelKey, "pool-worker"), poolWorkerLabels)
+	} else {
+		taskErr = taskFunc(c)
+	}
+	stats.record(time.Since(start), taskErr, false)
+	resultChn <- TaskResult{
+		err: taskErr,
+		id:  taskId,
+	}
+	if s != nil {
+		s.Finish()
+	}
+}
@@ -2,12 +2,15 @@ package koncurrent
 
 import (
 	"context"
+	"errors"
 	"github.com/opentracing/opentracing-go"
 	"testing"
+	"time"
 )
 
 func TestPoolExecutor_Execute(t *testing.T) {
 	underTest := NewPoolExecutor(10, 10)
+	defer underTest.Close()
 	var span opentracing.Span
 	resultChan := make(chan TaskResult)
 	underTest.Execute(context.Background(), func(ctx context.Context) error {
@@ -23,3 +26,58 @@ func TestPoolExecutor_Execute(t *testing.T) {
 	assertTrue(t, span != nil)
 	assertTrue(t, len(result.err.Error()) > 0)
 }
+
+func TestPoolExecutor_Stats(t *testing.T) {
+	underTest := NewPoolExecutor(2, 2)
+	defer underTest.Close()
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	resultChan := make(chan TaskResult, 3)
+	for i := 0; i < 3; i++ {
+		underTest.Execute(context.Background(), func(ctx context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}, i, resultChan, TaskExecutionOptions{})
+	}
+	<-started
+	<-started
+	stats := underTest.Stats()
+	assertEqual(t, 2, stats.Workers)
+	assertEqual(t, 2, stats.Busy)
+	assertEqual(t, 1, stats.Queued)
+	assertEqual(t, 2, stats.QueueCapacity)
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-resultChan
+	}
+}
+
+func TestPoolExecutor_StatsCounts(t *testing.T) {
+	underTest := NewPoolExecutor(2, 3)
+	defer underTest.Close()
+	resultChan := make(chan TaskResult, 3)
+	underTest.Execute(context.Background(), func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}, 0, resultChan, TaskExecutionOptions{})
+	underTest.Execute(context.Background(), func(ctx context.Context) error {
+		return errors.New("failed")
+	}, 1, resultChan, TaskExecutionOptions{})
+	underTest.Execute(context.Background(), func(ctx context.Context) error {
+		panic("panicked")
+	}, 2, resultChan, TaskExecutionOptions{})
+	for i := 0; i < 3; i++ {
+		<-resultChan
+	}
+	stats := underTest.Stats()
+	assertEqual(t, 0, stats.Busy)
+	assertEqual(t, uint64(3), stats.Completed)
+	assertEqual(t, uint64(1), stats.Failed)
+	assertEqual(t, uint64(1), stats.Panicked)
+	assertTrue(t, stats.Latency > 0)
+}
+
+func TestPoolExecutor_StatsZeroValue(t *testing.T) {
+	assertEqual(t, PoolStats{}, PoolExecutor{}.Stats())
+}
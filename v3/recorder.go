@@ -0,0 +1,166 @@
+package koncurrent
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type TaskRecord struct {
+	Stage int
+	Task  int
+	Name  string
+	// Submitted is when Await handed the task to its executor.
+	Submitted time.Time
+	// Started is when the first attempt of the task started running.
+	Started time.Time
+	// Finished is when Await received the outcome of the task.
+	Finished time.Time
+	// Attempts is the number of times the task function was started, more than one when hedged.
+	Attempts int
+	// CacheHit reports whether the outcome was served from the task cache without running the task.
+	CacheHit bool
+	// Deduplicated reports whether the task was skipped because a task with its idempotency key
+	// already succeeded.
+	Deduplicated bool
+	Err          error
+	// Runs are the attempts of the task function in the order they started.
+	Runs []TaskRun
+}
+
+// TaskRun is one attempt of a task function.
+type TaskRun struct {
+	Attempt   int
+	Goroutine uint64
+	Started   time.Time
+	Finished  time.Time
+	Err       error
+}
+
+func (r TaskRecord) Hedged() bool {
+	return r.Attempts > 1
+}
+
+// StageRecord is the timing of a stage, from Await submitting its first task to the outcome of the
+// stage.
+type StageRecord struct {
+	Stage    int
+	Parallel bool
+	Tasks    int
+	Started  time.Time
+	Finished time.Time
+	Err      error
+}
+
+// Recorder is an Observer that keeps one TaskRecord per task and one StageRecord per stage of a
+// single Await.
+type Recorder struct {
+	mu      sync.Mutex
+	records map[[2]int]*TaskRecord
+	stages  map[int]*StageRecord
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		records: make(map[[2]int]*TaskRecord),
+		stages:  make(map[int]*StageRecord),
+	}
+}
+
+func (r *Recorder) Observe(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.Kind == EventStageStart || event.Kind == EventStageFinish {
+		r.observeStage(event)
+		return
+	}
+	key := [2]int{event.Stage, event.Task}
+	record, ok := r.records[key]
+	if !ok {
+		record = &TaskRecord{
+			Stage: event.Stage,
+			Task:  event.Task,
+			Name:  event.Name,
+		}
+		r.records[key] = record
+	}
+	switch event.Kind {
+	case EventTaskSubmit:
+		record.Submitted = event.Time
+	case EventTaskStart:
+		if record.Attempts == 0 {
+			record.Started = event.Time
+		}
+		record.Attempts++
+		record.Runs = append(record.Runs, TaskRun{
+			Attempt:   event.Attempt,
+			Goroutine: event.Goroutine,
+			Started:   event.Time,
+		})
+	case EventTaskFinish:
+		for i := range record.Runs {
+			if record.Runs[i].Attempt == event.Attempt {
+				record.Runs[i].Finished = event.Time
+				record.Runs[i].Err = event.Err
+			}
+		}
+	case EventCacheHit:
+		record.CacheHit = true
+	case EventTaskDeduplicated:
+		record.Deduplicated = true
+	case EventTaskResult:
+		record.Finished = event.Time
+		record.Err = event.Err
+	}
+}
+
+func (r *Recorder) observeStage(event Event) {
+	stage, ok := r.stages[event.Stage]
+	if !ok {
+		stage = &StageRecord{
+			Stage:    event.Stage,
+			Parallel: event.Parallel,
+			Tasks:    event.Tasks,
+		}
+		r.stages[event.Stage] = stage
+	}
+	if event.Kind == EventStageStart {
+		stage.Started = event.Time
+	} else {
+		stage.Finished = event.Time
+		stage.Err = event.Err
+	}
+}
+
+// Stages returns the recorded stages in order.
+func (r *Recorder) Stages() []StageRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ret := make([]StageRecord, 0, len(r.stages))
+	for _, stage := range r.stages {
+		ret = append(ret, *stage)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Stage < ret[j].Stage
+	})
+	return ret
+}
+
+// Records returns the recorded tasks ordered by stage and task index.
+func (r *Recorder) Records() []TaskRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ret := make([]TaskRecord, 0, len(r.records))
+	for _, record := range r.records {
+		copied := *record
+		copied.Runs = append([]TaskRun(nil), record.Runs...)
+		ret = append(ret, copied)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Stage != ret[j].Stage {
+			return ret[i].Stage < ret[j].Stage
+		}
+		return ret[i].Task < ret[j].Task
+	})
+	return ret
+}
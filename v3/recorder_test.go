@@ -0,0 +1,50 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRecorder_Observe(t *testing.T) {
+	var t1 TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var t2 TaskFunc = func(ctx context.Context) error {
+		return errors.New("test")
+	}
+	var kinds []EventKind
+	recorder := NewRecorder()
+	_, err := ExecuteParallel(t1.Async().Name("t1"), t1.Async().Name("t2")).
+		ExecuteSerial(t1.Immediate().Name("t3"), t2.Immediate().Name("t4"), t1.Immediate().Name("t5")).
+		Observe(recorder).
+		Observe(ObserverFunc(func(event Event) {
+			if event.Stage == 1 && event.Task == 1 {
+				kinds = append(kinds, event.Kind)
+			}
+		})).
+		Await(context.Background())
+	assertNotNil(t, err)
+	records := recorder.Records()
+	assertEqual(t, 4, len(records))
+	assertEqual(t, "t1", records[0].Name)
+	assertEqual(t, "t4", records[3].Name)
+	assertEqual(t, 1, records[3].Stage)
+	assertEqual(t, 1, records[3].Task)
+	assertEqual(t, err, records[3].Err)
+	for _, record := range records {
+		assertEqual(t, 1, record.Attempts)
+		assertEqual(t, 1, len(record.Runs))
+		assertTrue(t, record.Runs[0].Goroutine != 0)
+		assertEqual(t, record.Err, record.Runs[0].Err)
+		assertTrue(t, !record.Hedged())
+		assertTrue(t, !record.Started.Before(record.Submitted))
+		assertTrue(t, !record.Finished.Before(record.Started))
+	}
+	assertEqual(t, 4, len(kinds))
+	assertEqual(t, EventTaskSubmit, kinds[0])
+	assertEqual(t, EventTaskStart, kinds[1])
+	assertEqual(t, EventTaskFinish, kinds[2])
+	assertEqual(t, EventTaskResult, kinds[3])
+	assertEqual(t, "task_result", kinds[3].String())
+}
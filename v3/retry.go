@@ -0,0 +1,74 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+)
+
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, the first one included.
+	Attempts int
+	// Backoff is the delay before the second attempt, doubled before every attempt after it up to
+	// MaxBackoff when that is positive.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable decides whether a failed attempt is retried. Defaults to retrying every error.
+	Retryable func(err error) bool
+	// RetryPanics retries the attempts that panic as if they returned a PanicError. Otherwise a
+	// panic is left to the executor and not retried.
+	RetryPanics bool
+}
+
+// Retry runs the task again when it fails, following the policy, until an attempt succeeds, the
+// attempts are exhausted or the context is done. The error of the last attempt is the outcome of
+// the task. Backoff delays are measured with the clock of the context.
+func (t TaskExecution) Retry(policy RetryPolicy) TaskExecution {
+	if policy.Attempts <= 1 {
+		return t
+	}
+	ret := t
+	taskFunc := t.taskFunc
+	ret.taskFunc = func(ctx context.Context) error {
+		return policy.run(ctx, taskFunc)
+	}
+	return ret
+}
+
+func (p RetryPolicy) run(ctx context.Context, taskFunc TaskFunc) error {
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := p.attempt(ctx, taskFunc)
+		if err == nil || attempt >= p.Attempts || (p.Retryable != nil && !p.Retryable(err)) {
+			return err
+		}
+		if backoff > 0 {
+			timer := ClockFromContext(ctx).NewTimer(backoff)
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+			backoff *= 2
+			if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+				backoff = p.MaxBackoff
+			}
+		} else if ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+func (p RetryPolicy) attempt(ctx context.Context, taskFunc TaskFunc) (err error) {
+	if p.RetryPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				err = PanicError{
+					Stack: debug.Stack(),
+				}
+			}
+		}()
+	}
+	return taskFunc(ctx)
+}
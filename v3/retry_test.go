@@ -0,0 +1,82 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTaskExecution_Retry(t *testing.T) {
+	clock := newFakeClock()
+	ctx := WithClock(context.Background(), clock)
+	calls := 0
+	var flaky TaskFunc = func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("test")
+		}
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := flaky.Immediate().Retry(RetryPolicy{Attempts: 3, Backoff: 10 * time.Millisecond}).Execution().Await(ctx)
+		done <- err
+	}()
+	clock.blockUntil(1)
+	clock.Advance(10 * time.Millisecond)
+	clock.blockUntil(1)
+	clock.Advance(20 * time.Millisecond)
+	assertNil(t, <-done)
+	assertEqual(t, 3, calls)
+
+	calls = 0
+	permanent := errors.New("permanent")
+	var failing TaskFunc = func(ctx context.Context) error {
+		calls++
+		return permanent
+	}
+	_, err := failing.Immediate().Retry(RetryPolicy{
+		Attempts: 5,
+		Retryable: func(err error) bool {
+			return err != permanent
+		},
+	}).Execution().Await(ctx)
+	assertEqual(t, permanent, err)
+	assertEqual(t, 1, calls)
+}
+
+func TestTaskExecution_RetryPanics(t *testing.T) {
+	calls := 0
+	var panicking TaskFunc = func(ctx context.Context) error {
+		calls++
+		panic("test panic")
+	}
+	_, err := panicking.Async().Retry(RetryPolicy{Attempts: 3}).Execution().Await(context.Background())
+	_, ok := err.(PanicError)
+	assertTrue(t, ok)
+	assertEqual(t, 1, calls)
+
+	calls = 0
+	_, err = panicking.Async().Retry(RetryPolicy{Attempts: 3, RetryPanics: true}).Execution().Await(context.Background())
+	_, ok = err.(PanicError)
+	assertTrue(t, ok)
+	assertEqual(t, 3, calls)
+}
+
+func TestTaskExecution_Timeout(t *testing.T) {
+	clock := newFakeClock()
+	var slow TaskFunc = func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		assertTrue(t, ok)
+		assertEqual(t, clock.Now().Add(time.Minute), deadline)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	go func() {
+		clock.blockUntil(1)
+		clock.Advance(time.Minute)
+	}()
+	_, err := slow.Async().Timeout(time.Minute).Execution().Await(WithClock(context.Background(), clock))
+	assertEqual(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,143 @@
+package koncurrent
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+type compensation struct {
+	taskFunc TaskFunc
+	policy   RetryPolicy
+}
+
+// Compensate sets the task function undoing the task. When Await aborts, because a stage failed or
+// the context is done, the compensations of the tasks that succeeded run one after another in the
+// reverse order of their completion, each retried following its policy. They run on the goroutine
+// calling Await, with a context that keeps the values of the Await context but is never done.
+// Tasks whose outcome Await did not receive before its context was done are not compensated.
+func (t TaskExecution) Compensate(taskFunc TaskFunc, policy RetryPolicy) TaskExecution {
+	ret := t
+	ret.compensation = &compensation{
+		taskFunc: taskFunc,
+		policy:   policy,
+	}
+	return ret
+}
+
+// Compensation is the outcome of the compensation of a task.
+type Compensation struct {
+	Stage int
+	Task  int
+	// Name is the name of the task, or its position in the execution if it has none.
+	Name string
+	// Err is the error of the last attempt of the compensation, nil if it succeeded.
+	Err error
+}
+
+// SagaError is the error of an Await that aborted and ran the compensations of the tasks that had
+// succeeded.
+type SagaError struct {
+	// Err is the error Await aborted with, the context error if its context was done.
+	Err error
+	// Compensations are the outcomes of the compensations, in the order they ran.
+	Compensations []Compensation
+}
+
+func (e SagaError) Error() string {
+	msg := fmt.Sprintf("%s: compensated %d tasks", e.Err, len(e.Compensations))
+	for _, c := range e.Failed() {
+		msg += fmt.Sprintf(": compensation of %s failed: %s", c.Name, c.Err)
+	}
+	return msg
+}
+
+func (e SagaError) Unwrap() error {
+	return e.Err
+}
+
+// Failed returns the compensations that failed.
+func (e SagaError) Failed() []Compensation {
+	var ret []Compensation
+	for _, c := range e.Compensations {
+		if c.Err != nil {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+type sagaStep struct {
+	stage        int
+	task         int
+	name         string
+	compensation *compensation
+}
+
+// saga keeps the tasks of an Await that succeeded and have a compensation, in the order of their
+// completion.
+type saga struct {
+	steps []sagaStep
+}
+
+// newSaga returns nil if no task of the execution has a compensation.
+func (e Execution) newSaga() *saga {
+	for _, tasks := range e.tasksList {
+		for _, task := range tasks {
+			if task.compensation != nil {
+				return &saga{}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *saga) record(stage int, task TaskExecution, taskResult TaskResult) {
+	if s == nil || task.compensation == nil || taskResult.err != nil {
+		return
+	}
+	s.steps = append(s.steps, sagaStep{
+		stage:        stage,
+		task:         taskResult.id,
+		name:         taskName(stage, taskResult.id, task.options.name),
+		compensation: task.compensation,
+	})
+}
+
+// compensate runs the compensations in reverse order and returns err wrapped in a SagaError, or err
+// itself if there was nothing to compensate.
+func (s *saga) compensate(ctx context.Context, err error) error {
+	if s == nil || len(s.steps) == 0 {
+		return err
+	}
+	ctx = detachedContext{ctx}
+	compensations := make([]Compensation, 0, len(s.steps))
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		step := s.steps[i]
+		compensations = append(compensations, Compensation{
+			Stage: step.stage,
+			Task:  step.task,
+			Name:  step.name,
+			Err:   step.compensation.policy.run(ctx, recovered(step.compensation.taskFunc)),
+		})
+	}
+	return SagaError{
+		Err:           err,
+		Compensations: compensations,
+	}
+}
+
+// recovered turns the panics of the task function into a PanicError, since there is no executor
+// to recover them.
+func recovered(taskFunc TaskFunc) TaskFunc {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = PanicError{
+					Stack: debug.Stack(),
+				}
+			}
+		}()
+		return taskFunc(ctx)
+	}
+}
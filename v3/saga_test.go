@@ -0,0 +1,118 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTaskExecution_Compensate(t *testing.T) {
+	var mu sync.Mutex
+	var undone []string
+	undo := func(name string) TaskFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			undone = append(undone, name)
+			return nil
+		}
+	}
+	var ok TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	errShipment := errors.New("no carrier")
+	var shipment TaskFunc = func(ctx context.Context) error {
+		return errShipment
+	}
+	results, err := ExecuteSerial(
+		ok.Immediate().Name("reserve").Compensate(undo("reserve"), RetryPolicy{}),
+		ok.Async().Name("charge").Compensate(undo("charge"), RetryPolicy{}),
+		ok.Immediate().Name("notify"),
+		shipment.Immediate().Name("ship").Compensate(undo("ship"), RetryPolicy{}),
+	).Await(context.Background())
+
+	assertEqual(t, errShipment, results[0][3])
+	assertTrue(t, errors.Is(err, errShipment))
+	sagaErr, isSaga := err.(SagaError)
+	assertTrue(t, isSaga)
+	assertEqual(t, "charge,reserve", strings.Join(undone, ","))
+	assertEqual(t, 2, len(sagaErr.Compensations))
+	assertEqual(t, Compensation{Stage: 0, Task: 1, Name: "charge"}, sagaErr.Compensations[0])
+	assertEqual(t, Compensation{Stage: 0, Task: 0, Name: "reserve"}, sagaErr.Compensations[1])
+	assertEqual(t, 0, len(sagaErr.Failed()))
+}
+
+func TestTaskExecution_CompensateNotAborted(t *testing.T) {
+	compensated := false
+	var ok TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	_, err := ExecuteSerial(ok.Immediate().Compensate(func(ctx context.Context) error {
+		compensated = true
+		return nil
+	}, RetryPolicy{})).Await(context.Background())
+	assertNil(t, err)
+	assertTrue(t, !compensated)
+}
+
+func TestTaskExecution_CompensateRetry(t *testing.T) {
+	attempts := 0
+	errRefund := errors.New("refund rejected")
+	var ok TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var failing TaskFunc = func(ctx context.Context) error {
+		return errors.New("failed")
+	}
+	_, err := ExecuteSerial(
+		ok.Immediate().Name("reserve").Compensate(func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errRefund
+			}
+			return nil
+		}, RetryPolicy{Attempts: 3}),
+		ok.Immediate().Name("charge").Compensate(func(ctx context.Context) error {
+			return errRefund
+		}, RetryPolicy{Attempts: 2}),
+		ok.Immediate().Name("label").Compensate(func(ctx context.Context) error {
+			panic("no label")
+		}, RetryPolicy{}),
+	).ExecuteSerial(failing.Immediate()).Await(context.Background())
+
+	sagaErr := err.(SagaError)
+	assertEqual(t, 3, attempts)
+	assertEqual(t, 3, len(sagaErr.Compensations))
+	failed := sagaErr.Failed()
+	assertEqual(t, 2, len(failed))
+	assertEqual(t, "label", failed[0].Name)
+	_, isPanic := failed[0].Err.(PanicError)
+	assertTrue(t, isPanic)
+	assertEqual(t, "charge", failed[1].Name)
+	assertEqual(t, errRefund, failed[1].Err)
+}
+
+func TestTaskExecution_CompensateCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var compensationCtxErr error
+	var ok TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var blocking TaskFunc = func(ctx context.Context) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	_, err := ExecuteSerial(ok.Immediate().Compensate(func(ctx context.Context) error {
+		compensationCtxErr = ctx.Err()
+		return nil
+	}, RetryPolicy{})).
+		ExecuteSerial(blocking.Async()).
+		Await(ctx)
+
+	assertTrue(t, errors.Is(err, context.Canceled))
+	assertEqual(t, 1, len(err.(SagaError).Compensations))
+	assertNil(t, compensationCtxErr)
+}
@@ -0,0 +1,188 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+type MissedRunPolicy int
+
+const (
+	// MissedRunCoalesce runs once as soon as possible for all the missed runs, then continues on the
+	// original schedule.
+	MissedRunCoalesce MissedRunPolicy = iota
+	// MissedRunSkip drops the missed runs and waits for the next run on the original schedule.
+	MissedRunSkip
+	// MissedRunCatchUp runs every missed run back to back.
+	MissedRunCatchUp
+)
+
+// Scheduler runs tasks after a delay, at a given time or periodically. The runs are executed by the
+// given executor, one at a time per schedule.
+type Scheduler struct {
+	executor TaskExecutor
+	clock    Clock
+}
+
+type ScheduledTask struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	mu      sync.Mutex
+	runs    int
+	missed  int
+	lastErr error
+}
+
+func NewScheduler(executor TaskExecutor, clock Clock) *Scheduler {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &Scheduler{
+		executor: executor,
+		clock:    clock,
+	}
+}
+
+func (s *Scheduler) Schedule(task TaskFunc, delay time.Duration) *ScheduledTask {
+	return s.ScheduleAt(task, s.clock.Now().Add(delay))
+}
+
+func (s *Scheduler) ScheduleAt(task TaskFunc, at time.Time) *ScheduledTask {
+	return s.start(func(ctx context.Context, st *ScheduledTask) {
+		if s.waitUntil(ctx, at) {
+			s.run(ctx, st, task)
+		}
+	})
+}
+
+// ScheduleAtFixedRate runs the task after initialDelay and then every period, applying the policy to
+// the runs missed while a run overran. Like time.NewTicker, it panics if period is not positive.
+func (s *Scheduler) ScheduleAtFixedRate(task TaskFunc, initialDelay time.Duration, period time.Duration, policy MissedRunPolicy) *ScheduledTask {
+	if period <= 0 {
+		panic("non-positive period for Scheduler.ScheduleAtFixedRate")
+	}
+	return s.start(func(ctx context.Context, st *ScheduledTask) {
+		next := s.clock.Now().Add(initialDelay)
+		for s.waitUntil(ctx, next) {
+			s.run(ctx, st, task)
+			next = next.Add(period)
+			late := s.clock.Now().Sub(next)
+			if late <= 0 || policy == MissedRunCatchUp {
+				continue
+			}
+			missed := int(late / period)
+			if policy == MissedRunSkip && late%period != 0 {
+				// the next run is the first one that is not late, a run due now included
+				missed++
+			}
+			next = next.Add(time.Duration(missed) * period)
+			st.mu.Lock()
+			st.missed += missed
+			st.mu.Unlock()
+		}
+	})
+}
+
+// ScheduleWithFixedDelay runs the task after initialDelay and then delay after each run finishes.
+// It panics if delay is not positive.
+func (s *Scheduler) ScheduleWithFixedDelay(task TaskFunc, initialDelay time.Duration, delay time.Duration) *ScheduledTask {
+	if delay <= 0 {
+		panic("non-positive delay for Scheduler.ScheduleWithFixedDelay")
+	}
+	return s.start(func(ctx context.Context, st *ScheduledTask) {
+		next := s.clock.Now().Add(initialDelay)
+		for s.waitUntil(ctx, next) {
+			s.run(ctx, st, task)
+			next = s.clock.Now().Add(delay)
+		}
+	})
+}
+
+func (s *Scheduler) start(loop func(ctx context.Context, st *ScheduledTask)) *ScheduledTask {
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &ScheduledTask{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		pprof.SetGoroutineLabels(schedulerLabels)
+		defer close(st.done)
+		defer cancel()
+		loop(ctx, st)
+	}()
+	return st
+}
+
+func (s *Scheduler) waitUntil(ctx context.Context, at time.Time) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	d := at.Sub(s.clock.Now())
+	if d <= 0 {
+		return true
+	}
+	timer := s.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, st *ScheduledTask, task TaskFunc) {
+	resultChn := make(chan TaskResult, 1)
+	s.executor.Execute(ctx, task, 0, resultChn, TaskExecutionOptions{})
+	var err error
+	select {
+	case result := <-resultChn:
+		err = result.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	st.mu.Lock()
+	st.runs++
+	st.lastErr = err
+	st.mu.Unlock()
+}
+
+// Cancel stops the schedule and cancels the context of a run in progress. It returns false if the
+// schedule had already finished.
+func (st *ScheduledTask) Cancel() bool {
+	select {
+	case <-st.done:
+		return false
+	default:
+	}
+	st.cancel()
+	return true
+}
+
+// Done is closed once the schedule has finished, either because it was cancelled or because its
+// single run completed.
+func (st *ScheduledTask) Done() <-chan struct{} {
+	return st.done
+}
+
+func (st *ScheduledTask) Runs() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.runs
+}
+
+// Missed returns the number of fixed rate runs that were coalesced or skipped.
+func (st *ScheduledTask) Missed() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.missed
+}
+
+// Err returns the error of the latest run.
+func (st *ScheduledTask) Err() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.lastErr
+}
@@ -0,0 +1,146 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Schedule(t *testing.T) {
+	clock := newFakeClock()
+	underTest := NewScheduler(AsyncExecutor{}, clock)
+	ran := make(chan time.Time, 1)
+	st := underTest.Schedule(func(ctx context.Context) error {
+		ran <- clock.Now()
+		return errors.New("test")
+	}, time.Minute)
+	start := clock.Now()
+	clock.blockUntil(1)
+	clock.Advance(59 * time.Second)
+	select {
+	case <-ran:
+		t.Error("task ran before its delay")
+	default:
+	}
+	clock.Advance(time.Second)
+	assertEqual(t, start.Add(time.Minute), <-ran)
+	<-st.Done()
+	assertEqual(t, 1, st.Runs())
+	assertNotNil(t, st.Err())
+	assertTrue(t, !st.Cancel())
+}
+
+func TestScheduler_Cancel(t *testing.T) {
+	clock := newFakeClock()
+	underTest := NewScheduler(ImmediateExecutor{}, clock)
+	st := underTest.ScheduleAt(func(ctx context.Context) error {
+		t.Error("cancelled task ran")
+		return nil
+	}, clock.Now().Add(time.Hour))
+	clock.blockUntil(1)
+	assertTrue(t, st.Cancel())
+	<-st.Done()
+	clock.Advance(time.Hour)
+	assertEqual(t, 0, st.Runs())
+}
+
+func TestScheduler_ScheduleAtFixedRate(t *testing.T) {
+	policies := []MissedRunPolicy{MissedRunCoalesce, MissedRunSkip, MissedRunCatchUp}
+	expectedSecondRun := []time.Duration{35, 40, 35}
+	expectedThirdRun := []time.Duration{40, 50, 35}
+	expectedMissed := []int{1, 2, 0}
+	for i, policy := range policies {
+		clock := newFakeClock()
+		start := clock.Now()
+		underTest := NewScheduler(ImmediateExecutor{}, clock)
+		ran := make(chan time.Duration, 1)
+		runs := 0
+		st := underTest.ScheduleAtFixedRate(func(ctx context.Context) error {
+			runs++
+			ran <- clock.Now().Sub(start) / time.Millisecond
+			if runs == 1 {
+				// the first run overruns two periods
+				clock.Advance(25 * time.Millisecond)
+			}
+			return nil
+		}, 10*time.Millisecond, 10*time.Millisecond, policy)
+		clock.blockUntil(1)
+		clock.Advance(10 * time.Millisecond)
+		assertEqual(t, time.Duration(10), <-ran)
+		for _, expected := range []time.Duration{expectedSecondRun[i], expectedThirdRun[i]} {
+			select {
+			case d := <-ran:
+				assertEqual(t, expected, d)
+			case <-time.After(10 * time.Millisecond):
+				clock.blockUntil(1)
+				clock.Advance(expected*time.Millisecond - clock.Now().Sub(start))
+				assertEqual(t, expected, <-ran)
+			}
+		}
+		st.Cancel()
+		<-st.Done()
+		assertEqual(t, expectedMissed[i], st.Missed())
+	}
+}
+
+func TestScheduler_ScheduleWithFixedDelay(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	underTest := NewScheduler(ImmediateExecutor{}, clock)
+	ran := make(chan time.Duration, 1)
+	st := underTest.ScheduleWithFixedDelay(func(ctx context.Context) error {
+		ran <- clock.Now().Sub(start)
+		clock.Advance(5 * time.Second)
+		return nil
+	}, 0, 10*time.Second)
+	assertEqual(t, time.Duration(0), <-ran)
+	clock.blockUntil(1)
+	clock.Advance(10 * time.Second)
+	assertEqual(t, 15*time.Second, <-ran)
+	st.Cancel()
+	<-st.Done()
+	assertEqual(t, 2, st.Runs())
+}
+
+func TestScheduler_ScheduleAtFixedRateSkipDueNow(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	underTest := NewScheduler(ImmediateExecutor{}, clock)
+	ran := make(chan time.Duration, 2)
+	runs := 0
+	st := underTest.ScheduleAtFixedRate(func(ctx context.Context) error {
+		runs++
+		ran <- clock.Now().Sub(start) / time.Millisecond
+		if runs == 1 {
+			// the first run overruns exactly one period, the next one is due when it returns
+			clock.Advance(20 * time.Millisecond)
+		}
+		return nil
+	}, 10*time.Millisecond, 10*time.Millisecond, MissedRunSkip)
+	clock.blockUntil(1)
+	clock.Advance(10 * time.Millisecond)
+	assertEqual(t, time.Duration(10), <-ran)
+	assertEqual(t, time.Duration(30), <-ran)
+	st.Cancel()
+	<-st.Done()
+	assertEqual(t, 1, st.Missed())
+}
+
+func TestScheduler_ScheduleAtFixedRateNonPositivePeriod(t *testing.T) {
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	NewScheduler(ImmediateExecutor{}, newFakeClock()).ScheduleAtFixedRate(func(ctx context.Context) error {
+		return nil
+	}, 0, 0, MissedRunCatchUp)
+}
+
+func TestScheduler_ScheduleWithFixedDelayNonPositiveDelay(t *testing.T) {
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	NewScheduler(ImmediateExecutor{}, newFakeClock()).ScheduleWithFixedDelay(func(ctx context.Context) error {
+		return nil
+	}, 0, -time.Second)
+}
@@ -2,6 +2,7 @@ package koncurrent
 
 import (
 	"context"
+	"time"
 )
 
 type TaskFunc func(ctx context.Context) error
@@ -24,14 +25,27 @@ type TaskResult struct {
 }
 
 type TaskExecution struct {
-	options  TaskExecutionOptions
-	taskFunc TaskFunc
-	executor TaskExecutor
+	options      TaskExecutionOptions
+	taskFunc     TaskFunc
+	executor     TaskExecutor
+	compensation *compensation
 }
 
 type TaskExecutionOptions struct {
-	tracingSpanName  string
-	recoverFromPanic bool
+	tracingSpanName    string
+	recoverFromPanic   bool
+	circuitBreakerName string
+	name               string
+	coalesceKey        string
+	stage              int
+	execution          string
+	profilerLabels     bool
+	observer           Observer
+	logger             *taskLogger
+}
+
+func (o TaskExecutionOptions) Name() string {
+	return o.name
 }
 
 func (t TaskExecution) Recover() TaskExecution {
@@ -40,12 +54,43 @@ func (t TaskExecution) Recover() TaskExecution {
 	return ret
 }
 
+func (t TaskExecution) CoalesceKey(key string) TaskExecution {
+	ret := t
+	ret.options.coalesceKey = key
+	return ret
+}
+
+func (t TaskExecution) Name(name string) TaskExecution {
+	ret := t
+	ret.options.name = name
+	return ret
+}
+
 func (t TaskExecution) Tracing(spanName string) TaskExecution {
 	ret := t
 	ret.options.tracingSpanName = spanName
 	return ret
 }
 
+func (t TaskExecution) CircuitBreaker(name string) TaskExecution {
+	ret := t
+	ret.options.circuitBreakerName = name
+	return ret
+}
+
+// Timeout cancels the context of the task after d, measured with the clock of the context. The task
+// has to return once its context is done for the timeout to take effect.
+func (t TaskExecution) Timeout(d time.Duration) TaskExecution {
+	ret := t
+	taskFunc := t.taskFunc
+	ret.taskFunc = func(ctx context.Context) error {
+		ctx, cancel := withClockTimeout(ctx, d)
+		defer cancel()
+		return taskFunc(ctx)
+	}
+	return ret
+}
+
 func (t TaskExecution) Execution() Execution {
 	return ExecuteSerial(t)
 }
@@ -70,3 +115,10 @@ func (t TaskFunc) Pool(executor PoolExecutor) TaskExecution {
 		executor: executor,
 	}
 }
+
+func (t TaskFunc) Executor(executor TaskExecutor) TaskExecution {
+	return TaskExecution{
+		taskFunc: t,
+		executor: executor,
+	}
+}
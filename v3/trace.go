@@ -0,0 +1,44 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/trace"
+	"strconv"
+)
+
+// traceTaskType is the runtime/trace task type of an Await of the execution.
+func (e Execution) traceTaskType() string {
+	if e.name != "" {
+		return e.name
+	}
+	return "koncurrent.Execution"
+}
+
+func (e Execution) traceStageRegionType(stage int) string {
+	if e.executionTypeList[stage] == executionTypeParallel {
+		return "stage" + strconv.Itoa(stage) + " parallel"
+	}
+	return "stage" + strconv.Itoa(stage) + " serial"
+}
+
+// tracedTaskFunc runs the task in a runtime/trace region named after it, on whichever goroutine
+// the executor runs it, and logs its error.
+func tracedTaskFunc(taskFunc TaskFunc, taskId int, opt TaskExecutionOptions) TaskFunc {
+	regionType := taskName(opt.stage, taskId, opt.name)
+	return func(ctx context.Context) error {
+		defer trace.StartRegion(ctx, regionType).End()
+		err := taskFunc(ctx)
+		if err != nil {
+			trace.Log(ctx, "error", err.Error())
+		}
+		return err
+	}
+}
+
+// taskName returns the name of the task, or its position in the execution if it has none.
+func taskName(stage int, taskId int, name string) string {
+	if name != "" {
+		return name
+	}
+	return "stage" + strconv.Itoa(stage) + "-task" + strconv.Itoa(taskId)
+}
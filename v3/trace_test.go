@@ -0,0 +1,34 @@
+package koncurrent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime/trace"
+	"testing"
+)
+
+func TestExecution_Await_RuntimeTrace(t *testing.T) {
+	pe := NewPoolExecutor(2, 2)
+	defer pe.Close()
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skip("tracing is already enabled")
+	}
+	var succeeding TaskFunc = func(ctx context.Context) error {
+		return nil
+	}
+	var failing TaskFunc = func(ctx context.Context) error {
+		return errors.New("out of stock")
+	}
+	results, err := ExecuteParallel(succeeding.Pool(pe).Name("load-cart"), succeeding.Async().Name("load-user")).
+		ExecuteSerial(failing.Immediate().Name("reserve-stock")).
+		Name("checkout").
+		Await(context.Background())
+	trace.Stop()
+	assertNotNil(t, err)
+	assertEqual(t, 2, len(results))
+	for _, s := range []string{"checkout", "stage0 parallel", "stage1 serial", "load-cart", "load-user", "reserve-stock", "out of stock"} {
+		assertTrue(t, bytes.Contains(buf.Bytes(), []byte(s)))
+	}
+}
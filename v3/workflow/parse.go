@@ -0,0 +1,490 @@
+package workflow
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+type valueKind int
+
+const (
+	scalarValue valueKind = iota
+	sequenceValue
+	mappingValue
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case sequenceValue:
+		return "a list"
+	case mappingValue:
+		return "a mapping"
+	default:
+		return "a value"
+	}
+}
+
+// value is a parsed JSON or YAML value with the position it starts at in the document.
+type value struct {
+	kind   valueKind
+	text   string
+	null   bool
+	items  []*value
+	keys   []*value
+	line   int
+	column int
+}
+
+func (v *value) get(key string) *value {
+	for i := range v.keys {
+		if v.keys[i].text == key {
+			return v.items[i]
+		}
+	}
+	return nil
+}
+
+// source maps the offsets of a document to lines and columns.
+type source struct {
+	data       []byte
+	lineStarts []int
+}
+
+func newSource(data []byte) *source {
+	s := &source{data: data, lineStarts: []int{0}}
+	for i, c := range data {
+		if c == '\n' {
+			s.lineStarts = append(s.lineStarts, i+1)
+		}
+	}
+	return s
+}
+
+func (s *source) position(offset int) (int, int) {
+	line := sort.Search(len(s.lineStarts), func(i int) bool {
+		return s.lineStarts[i] > offset
+	})
+	return line, offset - s.lineStarts[line-1] + 1
+}
+
+func (s *source) errorAt(offset int, format string, args ...interface{}) *Error {
+	line, column := s.position(offset)
+	return errorf(line, column, format, args...)
+}
+
+// flowParser parses JSON documents, and the flow collections and scalars of YAML documents, which
+// additionally allow plain scalars, single quoted strings and comments.
+type flowParser struct {
+	*source
+	pos   int
+	depth int
+	yaml  bool
+}
+
+func parseJSON(data []byte) (*value, error) {
+	p := &flowParser{source: newSource(data)}
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.data) {
+		return nil, p.errorAt(p.pos, "unexpected %q after the document", p.data[p.pos])
+	}
+	return v, nil
+}
+
+func (p *flowParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == '#' && p.yaml:
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *flowParser) newValue(kind valueKind) *value {
+	line, column := p.position(p.pos)
+	return &value{kind: kind, line: line, column: column}
+}
+
+func (p *flowParser) parseValue() (*value, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, p.errorAt(p.pos, "unexpected end of document")
+	}
+	switch p.data[p.pos] {
+	case '{':
+		return p.parseCollection(mappingValue, '}')
+	case '[':
+		return p.parseCollection(sequenceValue, ']')
+	case '"':
+		return p.parseDoubleQuoted()
+	case '\'':
+		if p.yaml {
+			return p.parseSingleQuoted()
+		}
+	}
+	return p.parsePlain()
+}
+
+func (p *flowParser) parseCollection(kind valueKind, end byte) (*value, error) {
+	v := p.newValue(kind)
+	p.pos++
+	p.depth++
+	defer func() {
+		p.depth--
+	}()
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == end {
+		p.pos++
+		return v, nil
+	}
+	for {
+		if kind == mappingValue {
+			key, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if key.kind != scalarValue {
+				return nil, errorf(key.line, key.column, "expected a key, found %s", key.kind)
+			}
+			p.skipSpace()
+			if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+				return nil, p.errorAt(p.pos, "expected ':' after key %q", key.text)
+			}
+			p.pos++
+			v.keys = append(v.keys, key)
+		}
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		v.items = append(v.items, item)
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, p.errorAt(p.pos, "expected %q, found end of document", end)
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case end:
+			p.pos++
+			return v, nil
+		default:
+			return nil, p.errorAt(p.pos, "expected ',' or %q, found %q", end, p.data[p.pos])
+		}
+	}
+}
+
+func (p *flowParser) parseDoubleQuoted() (*value, error) {
+	v := p.newValue(scalarValue)
+	start := p.pos
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos++
+		case '\n':
+			return nil, p.errorAt(start, "unterminated string")
+		case '"':
+			p.pos++
+			if err := json.Unmarshal(p.data[start:p.pos], &v.text); err != nil {
+				return nil, p.errorAt(start, "invalid string %s", p.data[start:p.pos])
+			}
+			return v, nil
+		}
+	}
+	return nil, p.errorAt(start, "unterminated string")
+}
+
+func (p *flowParser) parseSingleQuoted() (*value, error) {
+	v := p.newValue(scalarValue)
+	start := p.pos
+	var sb strings.Builder
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		switch c := p.data[p.pos]; c {
+		case '\n':
+			return nil, p.errorAt(start, "unterminated string")
+		case '\'':
+			if p.pos+1 < len(p.data) && p.data[p.pos+1] == '\'' {
+				sb.WriteByte('\'')
+				p.pos++
+				continue
+			}
+			p.pos++
+			v.text = sb.String()
+			return v, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return nil, p.errorAt(start, "unterminated string")
+}
+
+// parsePlain parses a JSON number or literal, or a YAML plain scalar.
+func (p *flowParser) parsePlain() (*value, error) {
+	v := p.newValue(scalarValue)
+	start := p.pos
+	for ; p.pos < len(p.data); p.pos++ {
+		c := p.data[p.pos]
+		if c == '\n' || c == '\r' {
+			break
+		}
+		if !p.yaml {
+			if c == ',' || c == ']' || c == '}' || c == ':' || isSpace(c) {
+				break
+			}
+			continue
+		}
+		if p.depth > 0 && (c == ',' || c == ']' || c == '}') {
+			break
+		}
+		if c == ':' && (p.pos+1 == len(p.data) || isSpace(p.data[p.pos+1]) ||
+			(p.depth > 0 && strings.IndexByte(",]}", p.data[p.pos+1]) >= 0)) {
+			break
+		}
+		if c == '#' && p.pos > start && isSpace(p.data[p.pos-1]) {
+			break
+		}
+	}
+	v.text = strings.TrimSpace(string(p.data[start:p.pos]))
+	if v.text == "" {
+		return nil, p.errorAt(start, "unexpected %q", p.data[start])
+	}
+	if !p.yaml {
+		var literal interface{}
+		if err := json.Unmarshal([]byte(v.text), &literal); err != nil {
+			return nil, p.errorAt(start, "invalid value %q", v.text)
+		}
+	}
+	v.null = v.text == "null" || (p.yaml && v.text == "~")
+	return v, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// yamlLine is a line of a YAML document with content, without its indentation and comment.
+type yamlLine struct {
+	number int
+	offset int
+	indent int
+	text   string
+}
+
+// yamlParser parses the block style subset of YAML used by workflow definitions: mappings,
+// sequences, scalars and flow collections, without anchors, tags or multi-line scalars.
+type yamlParser struct {
+	*source
+	lines []yamlLine
+	next  int
+}
+
+func parseYAML(data []byte) (*value, error) {
+	p := &yamlParser{source: newSource(data)}
+	for i, start := range p.lineStarts {
+		end := len(data)
+		if i+1 < len(p.lineStarts) {
+			end = p.lineStarts[i+1] - 1
+		}
+		text := strings.TrimRight(string(data[start:end]), "\r")
+		if strings.HasPrefix(text, "\t") {
+			return nil, errorf(i+1, 1, "tabs are not allowed for indentation")
+		}
+		content := strings.TrimLeft(text, " ")
+		content = strings.TrimSpace(stripComment(content))
+		if content == "" || content == "---" {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		p.lines = append(p.lines, yamlLine{
+			number: i + 1,
+			offset: start + indent,
+			indent: indent,
+			text:   content,
+		})
+	}
+	if len(p.lines) == 0 {
+		return nil, errorf(1, 1, "empty document")
+	}
+	v, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.next < len(p.lines) {
+		line := p.lines[p.next]
+		return nil, errorf(line.number, line.indent+1, "unexpected indentation")
+	}
+	return v, nil
+}
+
+// stripComment removes a comment, a '#' at the start or after a space outside of quotes.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey returns the key of a "key: value" line and the offset of its value, or false if the
+// line is not a mapping entry.
+func splitKey(text string) (string, int, bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		p := &flowParser{source: newSource([]byte(text)), yaml: true}
+		key, err := p.parseValue()
+		if err != nil || !strings.HasPrefix(text[p.pos:], ":") {
+			return "", 0, false
+		}
+		return key.text, p.pos + 1, true
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), i + 1, true
+		}
+		if text[i] == '[' || text[i] == '{' || text[i] == '"' {
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+func (p *yamlParser) parseBlock(indent int) (*value, error) {
+	line := p.lines[p.next]
+	if isSequenceItem(line.text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitKey(line.text); ok {
+		return p.parseMapping(indent)
+	}
+	return p.parseInline(line, 0)
+}
+
+func (p *yamlParser) parseSequence(indent int) (*value, error) {
+	first := p.lines[p.next]
+	v := &value{kind: sequenceValue, line: first.number, column: first.indent + 1}
+	for p.next < len(p.lines) {
+		line := p.lines[p.next]
+		if line.indent != indent || !isSequenceItem(line.text) {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.next++
+			if p.next >= len(p.lines) || p.lines[p.next].indent <= indent {
+				v.items = append(v.items, &value{null: true, line: line.number, column: line.indent + 1})
+				continue
+			}
+			item, err := p.parseBlock(p.lines[p.next].indent)
+			if err != nil {
+				return nil, err
+			}
+			v.items = append(v.items, item)
+			continue
+		}
+		// the content after "- " is a block of its own, indented as far as it starts
+		skipped := len(line.text) - len(rest)
+		p.lines[p.next] = yamlLine{
+			number: line.number,
+			offset: line.offset + skipped,
+			indent: line.indent + skipped,
+			text:   rest,
+		}
+		item, err := p.parseBlock(line.indent + skipped)
+		if err != nil {
+			return nil, err
+		}
+		v.items = append(v.items, item)
+	}
+	return v, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (*value, error) {
+	first := p.lines[p.next]
+	v := &value{kind: mappingValue, line: first.number, column: first.indent + 1}
+	for p.next < len(p.lines) {
+		line := p.lines[p.next]
+		if line.indent != indent || isSequenceItem(line.text) {
+			break
+		}
+		key, valueOffset, ok := splitKey(line.text)
+		if !ok {
+			return nil, errorf(line.number, line.indent+1, "expected a \"key: value\" entry")
+		}
+		if v.get(key) != nil {
+			return nil, errorf(line.number, line.indent+1, "duplicate key %q", key)
+		}
+		v.keys = append(v.keys, &value{text: key, line: line.number, column: line.indent + 1})
+		rest := strings.TrimLeft(line.text[valueOffset:], " ")
+		if rest != "" {
+			item, err := p.parseInline(line, len(line.text)-len(rest))
+			if err != nil {
+				return nil, err
+			}
+			v.items = append(v.items, item)
+			continue
+		}
+		p.next++
+		// a sequence may be indented as far as the key it belongs to
+		if p.next < len(p.lines) && (p.lines[p.next].indent > indent ||
+			(p.lines[p.next].indent == indent && isSequenceItem(p.lines[p.next].text))) {
+			item, err := p.parseBlock(p.lines[p.next].indent)
+			if err != nil {
+				return nil, err
+			}
+			v.items = append(v.items, item)
+			continue
+		}
+		v.items = append(v.items, &value{null: true, line: line.number, column: line.indent + 1})
+	}
+	return v, nil
+}
+
+// parseInline parses the scalar or flow collection starting at column of the line, which may span
+// the lines after it, and moves past the lines it spans.
+func (p *yamlParser) parseInline(line yamlLine, column int) (*value, error) {
+	fp := &flowParser{source: p.source, pos: line.offset + column, yaml: true}
+	v, err := fp.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	end := fp.pos
+	for end < len(p.data) && (p.data[end] == ' ' || p.data[end] == '\t') {
+		end++
+	}
+	endLine, endColumn := p.position(end)
+	lineEnd := len(p.data)
+	if endLine < len(p.lineStarts) {
+		lineEnd = p.lineStarts[endLine] - 1
+	}
+	rest := string(p.data[end:lineEnd])
+	if rest = strings.TrimSpace(stripComment(rest)); rest != "" {
+		return nil, errorf(endLine, endColumn, "unexpected %q", rest)
+	}
+	for p.next < len(p.lines) && p.lines[p.next].number <= endLine {
+		p.next++
+	}
+	return v, nil
+}
@@ -0,0 +1,80 @@
+package workflow
+
+import (
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	v, err := parseYAML([]byte(`
+# checkout flow
+name: "check: out"
+stages:
+- serial: [auth, 'it''s']
+- parallel:
+    - price   # inline comment
+    - {task: stock, timeout: 1s}
+    -
+      task: reviews
+      metadata:
+        duration: 10ms
+`))
+	assertNil(t, err)
+	assertEqual(t, mappingValue, v.kind)
+	assertEqual(t, "check: out", v.get("name").text)
+	stages := v.get("stages")
+	assertEqual(t, 2, len(stages.items))
+	serial := stages.items[0].get("serial")
+	assertEqual(t, 2, len(serial.items))
+	assertEqual(t, "it's", serial.items[1].text)
+	assertEqual(t, 5, serial.items[0].line)
+	assertEqual(t, 12, serial.items[0].column)
+	parallel := stages.items[1].get("parallel")
+	assertEqual(t, 3, len(parallel.items))
+	assertEqual(t, "price", parallel.items[0].text)
+	assertEqual(t, "1s", parallel.items[1].get("timeout").text)
+	assertEqual(t, "10ms", parallel.items[2].get("metadata").get("duration").text)
+	assertEqual(t, 10, parallel.items[2].line)
+	assertEqual(t, 7, parallel.items[2].column)
+}
+
+func TestParseJSON(t *testing.T) {
+	v, err := parseJSON([]byte(`{
+  "name": "checkout",
+  "stages": [{"serial": ["auth"]}, {"parallel": ["price", {"task": "stock", "retries": 2}]}]
+}`))
+	assertNil(t, err)
+	stages := v.get("stages")
+	assertEqual(t, "2", stages.items[1].get("parallel").items[1].get("retries").text)
+	assertEqual(t, 3, stages.items[1].line)
+	assertEqual(t, 36, stages.items[1].column)
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		json   bool
+		doc    string
+		line   int
+		column int
+	}{
+		{json: true, doc: "{\n  \"stages\": [\"a\",, ]\n}", line: 2, column: 18},
+		{json: true, doc: "{\"name\": \"a}", line: 1, column: 10},
+		{json: true, doc: "{\"name\": nope}", line: 1, column: 10},
+		{doc: "stages:\n  - serial: [a, b\n", line: 3, column: 1},
+		{doc: "name: a\n  stages: []\n", line: 2, column: 3},
+		{doc: "name: a\nname: b\n", line: 2, column: 1},
+		{doc: "stages: [a] b\n", line: 1, column: 13},
+	} {
+		var err error
+		if tc.json {
+			_, err = parseJSON([]byte(tc.doc))
+		} else {
+			_, err = parseYAML([]byte(tc.doc))
+		}
+		if assertNotNil(t, err); err != nil {
+			e := err.(*Error)
+			if e.Line != tc.line || e.Column != tc.column {
+				t.Errorf("%q: unexpected position of %v, expected %d:%d", tc.doc, e, tc.line, tc.column)
+			}
+		}
+	}
+}
@@ -0,0 +1,396 @@
+// Package workflow builds koncurrent executions from declarative definitions, JSON or YAML documents
+// listing the stages of the execution and the tasks they run by name:
+//
+//	name: checkout
+//	executor: pool
+//	stages:
+//	  - serial: [auth]
+//	  - parallel:
+//	      - price
+//	      - task: stock
+//	        timeout: 200ms
+//	        retries: 2
+//	        backoff: 10ms
+//	      - reviews
+//	  - serial: [render]
+//
+// The task functions and executors are looked up by name in a Registry.
+package workflow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/raymond852/koncurrent/v3"
+)
+
+// Error is a problem found at a line and column of a definition.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+func errorf(line int, column int, format string, args ...interface{}) *Error {
+	return &Error{
+		Line:   line,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// ErrorList is every problem found in a definition, in the order of the document.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i := range l {
+		msgs[i] = l[i].Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+type PanicPolicy string
+
+const (
+	// PanicFail fails the node with a koncurrent.PanicError when its task panics.
+	PanicFail PanicPolicy = "fail"
+	// PanicRetry retries the node when its task panics, like when it returns an error. A node with
+	// this policy must have retries.
+	PanicRetry PanicPolicy = "retry"
+)
+
+type Definition struct {
+	Name string
+	// Executor is the name of the executor of the nodes that do not name one. Defaults to
+	// "immediate".
+	Executor string
+	Stages   []Stage
+}
+
+type Stage struct {
+	Parallel bool
+	Nodes    []Node
+	Line     int
+	Column   int
+}
+
+// Node is a task of a stage. Timeout applies to every attempt of the task.
+type Node struct {
+	// Name names the task in the execution. Defaults to Task.
+	Name string
+	// Task is the name of the registered task function. Defaults to Name.
+	Task     string
+	Executor string
+	Timeout  time.Duration
+	Retries  int
+	Backoff  time.Duration
+	Panic    PanicPolicy
+	// Metadata holds free-form settings for tools processing the definition.
+	Metadata map[string]string
+	Line     int
+	Column   int
+}
+
+// ParseJSON parses a JSON definition.
+func ParseJSON(data []byte) (*Definition, error) {
+	v, err := parseJSON(data)
+	if err != nil {
+		return nil, ErrorList{err.(*Error)}
+	}
+	return decode(v)
+}
+
+// ParseYAML parses a YAML definition. Only the block and flow mappings, sequences and scalars of
+// YAML are supported.
+func ParseYAML(data []byte) (*Definition, error) {
+	v, err := parseYAML(data)
+	if err != nil {
+		return nil, ErrorList{err.(*Error)}
+	}
+	return decode(v)
+}
+
+// Load reads and parses the definition file at path, as JSON if its extension is .json and as YAML
+// otherwise. The errors are reported with the path of the file.
+func Load(path string) (*Definition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var def *Definition
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		def, err = ParseJSON(data)
+	} else {
+		def, err = ParseYAML(data)
+	}
+	if list, ok := err.(ErrorList); ok {
+		for i := range list {
+			list[i].File = path
+		}
+	}
+	return def, err
+}
+
+type decoder struct {
+	errs ErrorList
+}
+
+func (d *decoder) errorf(v *value, format string, args ...interface{}) {
+	d.errs = append(d.errs, errorf(v.line, v.column, format, args...))
+}
+
+func (d *decoder) expect(v *value, kind valueKind, what string) bool {
+	if v.kind != kind || (kind == scalarValue && v.null) {
+		d.errorf(v, "%s must be %s", what, kind)
+		return false
+	}
+	return true
+}
+
+func decode(v *value) (*Definition, error) {
+	d := &decoder{}
+	def := &Definition{}
+	if d.expect(v, mappingValue, "the definition") {
+		for i, key := range v.keys {
+			item := v.items[i]
+			switch key.text {
+			case "name":
+				if d.expect(item, scalarValue, "name") {
+					def.Name = item.text
+				}
+			case "executor":
+				if d.expect(item, scalarValue, "executor") {
+					def.Executor = item.text
+				}
+			case "stages":
+				if d.expect(item, sequenceValue, "stages") {
+					for _, stage := range item.items {
+						def.Stages = append(def.Stages, d.decodeStage(stage))
+					}
+				}
+			default:
+				d.errorf(key, "unknown field %q", key.text)
+			}
+		}
+		if v.get("stages") == nil {
+			d.errorf(v, "missing stages")
+		}
+	}
+	if len(d.errs) > 0 {
+		return nil, d.errs
+	}
+	return def, nil
+}
+
+func (d *decoder) decodeStage(v *value) Stage {
+	stage := Stage{Line: v.line, Column: v.column}
+	if !d.expect(v, mappingValue, "a stage") {
+		return stage
+	}
+	if len(v.keys) != 1 || (v.keys[0].text != "serial" && v.keys[0].text != "parallel") {
+		d.errorf(v, "a stage must have a single serial or parallel field")
+		return stage
+	}
+	stage.Parallel = v.keys[0].text == "parallel"
+	nodes := v.items[0]
+	if !d.expect(nodes, sequenceValue, "the tasks of a stage") {
+		return stage
+	}
+	if len(nodes.items) == 0 {
+		d.errorf(nodes, "a stage must have tasks")
+	}
+	for _, node := range nodes.items {
+		stage.Nodes = append(stage.Nodes, d.decodeNode(node))
+	}
+	return stage
+}
+
+func (d *decoder) decodeNode(v *value) Node {
+	node := Node{Line: v.line, Column: v.column}
+	if v.kind == scalarValue && !v.null {
+		node.Name = v.text
+		node.Task = v.text
+		return node
+	}
+	if !d.expect(v, mappingValue, "a task") {
+		return node
+	}
+	var panicItem *value
+	for i, key := range v.keys {
+		item := v.items[i]
+		if key.text == "metadata" {
+			if d.expect(item, mappingValue, "metadata") {
+				node.Metadata = make(map[string]string, len(item.keys))
+				for j := range item.keys {
+					if d.expect(item.items[j], scalarValue, "metadata "+item.keys[j].text) {
+						node.Metadata[item.keys[j].text] = item.items[j].text
+					}
+				}
+			}
+			continue
+		}
+		if !d.expect(item, scalarValue, key.text) {
+			continue
+		}
+		var err error
+		switch key.text {
+		case "name":
+			node.Name = item.text
+		case "task":
+			node.Task = item.text
+		case "executor":
+			node.Executor = item.text
+		case "timeout":
+			node.Timeout, err = time.ParseDuration(item.text)
+		case "backoff":
+			node.Backoff, err = time.ParseDuration(item.text)
+		case "retries":
+			node.Retries, err = strconv.Atoi(item.text)
+			if err == nil && node.Retries < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "panic":
+			node.Panic = PanicPolicy(item.text)
+			panicItem = item
+			if node.Panic != PanicFail && node.Panic != PanicRetry {
+				d.errorf(item, "panic must be %q or %q", PanicFail, PanicRetry)
+			}
+		default:
+			d.errorf(key, "unknown field %q", key.text)
+		}
+		if err != nil {
+			d.errorf(item, "invalid %s %q: %s", key.text, item.text, err)
+		}
+	}
+	if node.Panic == PanicRetry && node.Retries == 0 {
+		d.errorf(panicItem, "panic %q needs retries", PanicRetry)
+	}
+	if node.Name == "" {
+		node.Name = node.Task
+	}
+	if node.Task == "" {
+		node.Task = node.Name
+	}
+	if node.Task == "" {
+		d.errorf(v, "a task must have a name or task field")
+	}
+	return node
+}
+
+// Registry holds the task functions and executors that definitions refer to by name. The
+// "immediate" and "async" executors are registered by default.
+type Registry struct {
+	tasks     map[string]koncurrent.TaskFunc
+	executors map[string]koncurrent.TaskExecutor
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		tasks: make(map[string]koncurrent.TaskFunc),
+		executors: map[string]koncurrent.TaskExecutor{
+			"immediate": koncurrent.ImmediateExecutor{},
+			"async":     koncurrent.AsyncExecutor{},
+		},
+	}
+}
+
+func (r *Registry) RegisterTask(name string, task koncurrent.TaskFunc) {
+	r.tasks[name] = task
+}
+
+func (r *Registry) RegisterExecutor(name string, executor koncurrent.TaskExecutor) {
+	r.executors[name] = executor
+}
+
+// ExecutorOf returns the name of the executor of the node.
+func (d *Definition) ExecutorOf(node Node) string {
+	switch {
+	case node.Executor != "":
+		return node.Executor
+	case d.Executor != "":
+		return d.Executor
+	default:
+		return "immediate"
+	}
+}
+
+// Validate reports the tasks and executors of the definition missing from the registry, and the
+// tasks named more than once.
+func (d *Definition) Validate(registry *Registry) error {
+	var errs ErrorList
+	if len(d.Stages) == 0 {
+		errs = append(errs, errorf(1, 1, "the definition has no stages"))
+	}
+	names := map[string]bool{}
+	for _, stage := range d.Stages {
+		for _, node := range stage.Nodes {
+			if _, ok := registry.tasks[node.Task]; !ok {
+				errs = append(errs, errorf(node.Line, node.Column, "unknown task %q", node.Task))
+			}
+			if _, ok := registry.executors[d.ExecutorOf(node)]; !ok {
+				errs = append(errs, errorf(node.Line, node.Column, "unknown executor %q", d.ExecutorOf(node)))
+			}
+			if names[node.Name] {
+				errs = append(errs, errorf(node.Line, node.Column, "duplicate task name %q", node.Name))
+			}
+			names[node.Name] = true
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Build validates the definition and returns its execution.
+func (d *Definition) Build(registry *Registry) (koncurrent.Execution, error) {
+	var execution koncurrent.Execution
+	if err := d.Validate(registry); err != nil {
+		return execution, err
+	}
+	for i, stage := range d.Stages {
+		tasks := make([]koncurrent.TaskExecution, len(stage.Nodes))
+		for j, node := range stage.Nodes {
+			tasks[j] = node.taskExecution(registry.tasks[node.Task], registry.executors[d.ExecutorOf(node)])
+		}
+		switch {
+		case i == 0 && stage.Parallel:
+			execution = koncurrent.ExecuteParallel(tasks...)
+		case i == 0:
+			execution = koncurrent.ExecuteSerial(tasks...)
+		case stage.Parallel:
+			execution = execution.ExecuteParallel(tasks...)
+		default:
+			execution = execution.ExecuteSerial(tasks...)
+		}
+	}
+	return execution.Name(d.Name), nil
+}
+
+func (n Node) taskExecution(task koncurrent.TaskFunc, executor koncurrent.TaskExecutor) koncurrent.TaskExecution {
+	ret := task.Executor(executor).Name(n.Name)
+	if n.Timeout > 0 {
+		ret = ret.Timeout(n.Timeout)
+	}
+	if n.Retries > 0 {
+		ret = ret.Retry(koncurrent.RetryPolicy{
+			Attempts:    n.Retries + 1,
+			Backoff:     n.Backoff,
+			RetryPanics: n.Panic == PanicRetry,
+		})
+	}
+	return ret
+}
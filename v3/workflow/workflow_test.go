@@ -0,0 +1,162 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raymond852/koncurrent/v3"
+)
+
+const checkoutYAML = `
+name: checkout
+executor: async
+stages:
+  - serial: [auth]
+  - parallel:
+      - price
+      - task: stock
+        timeout: 200ms
+        retries: 2
+        panic: retry
+      - name: reviews
+        executor: immediate
+        metadata:
+          duration: 20ms
+  - serial: [render]
+`
+
+const checkoutJSON = `{
+  "name": "checkout",
+  "executor": "async",
+  "stages": [
+    {"serial": ["auth"]},
+    {"parallel": [
+      "price",
+      {"task": "stock", "timeout": "200ms", "retries": 2, "panic": "retry"},
+      {"name": "reviews", "executor": "immediate", "metadata": {"duration": "20ms"}}
+    ]},
+    {"serial": ["render"]}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	fromYAML, err := ParseYAML([]byte(checkoutYAML))
+	assertNil(t, err)
+	fromJSON, err := ParseJSON([]byte(checkoutJSON))
+	assertNil(t, err)
+	for _, def := range []*Definition{fromYAML, fromJSON} {
+		assertEqual(t, "checkout", def.Name)
+		assertEqual(t, "async", def.Executor)
+		assertEqual(t, 3, len(def.Stages))
+		assertTrue(t, def.Stages[1].Parallel)
+		stock := def.Stages[1].Nodes[1]
+		assertEqual(t, "stock", stock.Name)
+		assertEqual(t, 200*time.Millisecond, stock.Timeout)
+		assertEqual(t, 2, stock.Retries)
+		assertEqual(t, PanicRetry, stock.Panic)
+		reviews := def.Stages[1].Nodes[2]
+		assertEqual(t, "reviews", reviews.Task)
+		assertEqual(t, "immediate", reviews.Executor)
+		assertEqual(t, "20ms", reviews.Metadata["duration"])
+	}
+	assertEqual(t, 8, fromYAML.Stages[1].Nodes[1].Line)
+	assertEqual(t, 8, fromJSON.Stages[1].Nodes[1].Line)
+}
+
+func TestParse_InvalidFields(t *testing.T) {
+	_, err := ParseYAML([]byte(`
+stages:
+  - serial: [auth]
+    parallel: [price]
+  - parallel:
+      - task: stock
+        timeout: soon
+        retry: 2
+        panic: retry
+`))
+	assertNotNil(t, err)
+	list := err.(ErrorList)
+	assertEqual(t, 4, len(list))
+	assertEqual(t, "3:5: a stage must have a single serial or parallel field", list[0].Error())
+	assertEqual(t, `7:18: invalid timeout "soon": time: invalid duration "soon"`, list[1].Error())
+	assertEqual(t, `8:9: unknown field "retry"`, list[2].Error())
+	assertEqual(t, `9:16: panic "retry" needs retries`, list[3].Error())
+}
+
+func TestDefinition_Build(t *testing.T) {
+	var mu sync.Mutex
+	var trace []string
+	record := func(name string) koncurrent.TaskFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			trace = append(trace, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+	stockCalls := 0
+	registry := NewRegistry()
+	for _, name := range []string{"auth", "price", "reviews", "render"} {
+		registry.RegisterTask(name, record(name))
+	}
+	registry.RegisterTask("stock", func(ctx context.Context) error {
+		if stockCalls++; stockCalls < 3 {
+			panic("test panic")
+		}
+		return nil
+	})
+	def, err := ParseYAML([]byte(checkoutYAML))
+	assertNil(t, err)
+	execution, err := def.Build(registry)
+	assertNil(t, err)
+	plan := execution.Describe()
+	assertEqual(t, "checkout", plan.Name)
+	assertEqual(t, "async", plan.Stages[1].Tasks[0].Executor)
+	assertEqual(t, "immediate", plan.Stages[1].Tasks[2].Executor)
+
+	_, err = execution.Await(context.Background())
+	assertNil(t, err)
+	assertEqual(t, 3, stockCalls)
+	assertEqual(t, 4, len(trace))
+	assertEqual(t, "auth", trace[0])
+	assertEqual(t, "render", trace[3])
+}
+
+func TestDefinition_Validate(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterTask("auth", func(ctx context.Context) error {
+		return nil
+	})
+	def, err := ParseJSON([]byte(`{
+  "stages": [
+    {"serial": ["auth", "prise"]},
+    {"parallel": [{"task": "auth", "executor": "pool"}]}
+  ]
+}`))
+	assertNil(t, err)
+	_, err = def.Build(registry)
+	var list ErrorList
+	assertTrue(t, errors.As(err, &list))
+	assertEqual(t, 3, len(list))
+	assertEqual(t, `3:25: unknown task "prise"`, list[0].Error())
+	assertEqual(t, `4:19: unknown executor "pool"`, list[1].Error())
+	assertEqual(t, `4:19: duplicate task name "auth"`, list[2].Error())
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workflow")
+	assertNil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkout.json")
+	assertNil(t, ioutil.WriteFile(path, []byte(`{"stages": [{"serial": 1}]}`), 0644))
+	_, err = Load(path)
+	assertNotNil(t, err)
+	assertTrue(t, strings.HasPrefix(err.Error(), path+":1:24: "))
+}
@@ -0,0 +1,191 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// WorkStealingPoolExecutor is a fixed size pool where every worker owns a deque of tasks. Submitted
+// tasks are spread over the deques round robin, a worker runs the newest task of its own deque and,
+// when the deque is empty, steals the oldest task of a randomly chosen other worker. It avoids the
+// single shared channel of PoolExecutor, which is contended with many short CPU bound tasks.
+type WorkStealingPoolExecutor struct {
+	deques []workDeque
+	next   uint32
+	idle   int32
+	mu     sync.Mutex
+	wakeup *sync.Cond
+	closed bool
+	// profilerLabels is set before the pool is used and only read afterwards
+	profilerLabels bool
+}
+
+// cacheLineSize is the size of the cache lines of the common amd64 and arm64 processors.
+const cacheLineSize = 64
+
+type workDequeState struct {
+	mu    sync.Mutex
+	tasks []taskContext
+	head  int
+}
+
+type workDeque struct {
+	workDequeState
+	// padding to a whole number of cache lines avoids false sharing between the deques of
+	// neighbouring workers
+	_ [cacheLineSize - unsafe.Sizeof(workDequeState{})%cacheLineSize]byte
+}
+
+// NewWorkStealingPoolExecutor panics if poolSize is not positive.
+func NewWorkStealingPoolExecutor(poolSize int) *WorkStealingPoolExecutor {
+	if poolSize <= 0 {
+		panic("non-positive pool size for NewWorkStealingPoolExecutor")
+	}
+	ret := &WorkStealingPoolExecutor{
+		deques: make([]workDeque, poolSize),
+	}
+	ret.wakeup = sync.NewCond(&ret.mu)
+	for i := 0; i < poolSize; i++ {
+		go ret.work(i)
+	}
+	return ret
+}
+
+func (p *WorkStealingPoolExecutor) Execute(ctx context.Context, task TaskFunc, taskId int, resultChn chan TaskResult, opt TaskExecutionOptions) {
+	i := atomic.AddUint32(&p.next, 1) % uint32(len(p.deques))
+	p.deques[i].pushBottom(taskContext{
+		Context:   ctx,
+		task:      task,
+		taskId:    taskId,
+		resultChn: resultChn,
+		opt:       opt,
+		labels:    taskLabels(p.profilerLabels, opt, taskId, "work-stealing", ""),
+	})
+	if atomic.LoadInt32(&p.idle) > 0 {
+		p.mu.Lock()
+		p.wakeup.Signal()
+		p.mu.Unlock()
+	}
+}
+
+// ProfilerLabels makes the pool run every task with pprof labels for the executor, execution, stage
+// and task, whether or not its execution asked for them. It must be called before the pool is used,
+// and returns the pool.
+func (p *WorkStealingPoolExecutor) ProfilerLabels() *WorkStealingPoolExecutor {
+	p.profilerLabels = true
+	return p
+}
+
+// Close stops the workers of the pool once the submitted tasks have run. Tasks must not be
+// submitted to a closed pool.
+func (p *WorkStealingPoolExecutor) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.wakeup.Broadcast()
+	p.mu.Unlock()
+}
+
+func (p *WorkStealingPoolExecutor) work(self int) {
+	pprof.SetGoroutineLabels(poolWorkerLabels)
+	// xorshift state for picking victims, seeded differently per worker
+	seed := uint32(self)*2654435761 + 1
+	for {
+		taskCtx, ok := p.deques[self].popBottom()
+		if !ok {
+			taskCtx, ok = p.steal(self, &seed)
+		}
+		if !ok {
+			var closed bool
+			if taskCtx, ok, closed = p.park(self, &seed); closed {
+				return
+			}
+		}
+		if ok {
+			runTaskContext(taskCtx, nil)
+		}
+	}
+}
+
+func (p *WorkStealingPoolExecutor) steal(self int, seed *uint32) (taskContext, bool) {
+	n := len(p.deques)
+	*seed ^= *seed << 13
+	*seed ^= *seed >> 17
+	*seed ^= *seed << 5
+	start := int(*seed % uint32(n))
+	for i := 0; i < n; i++ {
+		victim := (start + i) % n
+		if victim == self {
+			continue
+		}
+		if taskCtx, ok := p.deques[victim].popTop(); ok {
+			return taskCtx, true
+		}
+	}
+	return taskContext{}, false
+}
+
+// park waits for a task to be submitted. It reports closed once the pool is closed and no task is
+// left to run.
+func (p *WorkStealingPoolExecutor) park(self int, seed *uint32) (taskContext, bool, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	atomic.AddInt32(&p.idle, 1)
+	defer atomic.AddInt32(&p.idle, -1)
+	// a task pushed before idle was incremented is found by this rescan, one pushed after it
+	// signals the condition once the worker waits on it
+	if taskCtx, ok := p.deques[self].popBottom(); ok {
+		return taskCtx, true, false
+	}
+	if taskCtx, ok := p.steal(self, seed); ok {
+		return taskCtx, true, false
+	}
+	if p.closed {
+		return taskContext{}, false, true
+	}
+	p.wakeup.Wait()
+	return taskContext{}, false, false
+}
+
+func (d *workDeque) pushBottom(taskCtx taskContext) {
+	d.mu.Lock()
+	d.tasks = append(d.tasks, taskCtx)
+	d.mu.Unlock()
+}
+
+func (d *workDeque) popBottom() (taskContext, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := len(d.tasks)
+	if n == d.head {
+		return taskContext{}, false
+	}
+	taskCtx := d.tasks[n-1]
+	d.tasks[n-1] = taskContext{}
+	d.tasks = d.tasks[:n-1]
+	d.reset()
+	return taskCtx, true
+}
+
+func (d *workDeque) popTop() (taskContext, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.tasks) == d.head {
+		return taskContext{}, false
+	}
+	taskCtx := d.tasks[d.head]
+	d.tasks[d.head] = taskContext{}
+	d.head++
+	d.reset()
+	return taskCtx, true
+}
+
+// reset rewinds an empty deque to the start of its backing array so that it is reused
+func (d *workDeque) reset() {
+	if len(d.tasks) == d.head {
+		d.tasks = d.tasks[:0]
+		d.head = 0
+	}
+}
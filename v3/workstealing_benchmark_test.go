@@ -0,0 +1,63 @@
+package koncurrent
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func cpuBoundTask(ctx context.Context) error {
+	x := uint32(1)
+	for i := 0; i < 200; i++ {
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+	}
+	if x == 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func benchmarkSubmitters(b *testing.B, newExecutor func() (TaskExecutor, func())) {
+	for _, submitters := range []int{1, 8, 64} {
+		b.Run(strconv.Itoa(submitters), func(b *testing.B) {
+			executor, closeExecutor := newExecutor()
+			defer closeExecutor()
+			var task TaskFunc = cpuBoundTask
+			b.ReportAllocs()
+			b.ResetTimer()
+			wg := sync.WaitGroup{}
+			for s := 0; s < submitters; s++ {
+				n := b.N / submitters
+				if s < b.N%submitters {
+					n++
+				}
+				wg.Add(1)
+				go func(n int) {
+					defer wg.Done()
+					for i := 0; i < n; i++ {
+						_, _ = ExecuteParallel(task.Executor(executor), task.Executor(executor)).Await(context.Background())
+					}
+				}(n)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func BenchmarkSubmitters_Pool(b *testing.B) {
+	benchmarkSubmitters(b, func() (TaskExecutor, func()) {
+		pe := NewPoolExecutor(runtime.GOMAXPROCS(0), 128)
+		return pe, pe.Close
+	})
+}
+
+func BenchmarkSubmitters_WorkStealingPool(b *testing.B) {
+	benchmarkSubmitters(b, func() (TaskExecutor, func()) {
+		pe := NewWorkStealingPoolExecutor(runtime.GOMAXPROCS(0))
+		return pe, pe.Close
+	})
+}
@@ -0,0 +1,71 @@
+package koncurrent
+
+import (
+	"context"
+	"errors"
+	"github.com/opentracing/opentracing-go"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+func TestWorkStealingPoolExecutor_Execute(t *testing.T) {
+	underTest := NewWorkStealingPoolExecutor(4)
+	defer underTest.Close()
+	var span opentracing.Span
+	resultChan := make(chan TaskResult)
+	underTest.Execute(context.Background(), func(ctx context.Context) error {
+		span = opentracing.SpanFromContext(ctx)
+		panic("test panic")
+	}, 0, resultChan, TaskExecutionOptions{
+		tracingSpanName: "test",
+	})
+	result := <-resultChan
+	_, ok := result.err.(PanicError)
+	assertTrue(t, ok)
+	assertTrue(t, span != nil)
+}
+
+func TestWorkStealingPoolExecutor_Steal(t *testing.T) {
+	underTest := NewWorkStealingPoolExecutor(4)
+	defer underTest.Close()
+	var count int32
+	var task TaskFunc = func(ctx context.Context) error {
+		if atomic.AddInt32(&count, 1)%100 == 0 {
+			return errors.New("test")
+		}
+		return nil
+	}
+	tasks := make([]TaskExecution, 1000)
+	for i := range tasks {
+		tasks[i] = task.Executor(underTest)
+	}
+	results, err := ExecuteParallel(tasks...).Await(context.Background())
+	assertNotNil(t, err)
+	assertEqual(t, 10, len(results.FlattenErrors()))
+	assertEqual(t, int32(1000), atomic.LoadInt32(&count))
+
+	var d workDeque
+	for i := 0; i < 3; i++ {
+		d.pushBottom(taskContext{taskId: i})
+	}
+	top, _ := d.popTop()
+	bottom, _ := d.popBottom()
+	assertEqual(t, 0, top.taskId)
+	assertEqual(t, 2, bottom.taskId)
+	d.popTop()
+	_, ok := d.popBottom()
+	assertTrue(t, !ok)
+	assertEqual(t, 0, d.head)
+}
+
+func TestWorkStealingPoolExecutor_Padding(t *testing.T) {
+	assertEqual(t, uintptr(0), unsafe.Sizeof(workDeque{})%cacheLineSize)
+}
+
+func TestNewWorkStealingPoolExecutor_NonPositiveSize(t *testing.T) {
+	defer func() {
+		assertTrue(t, recover() != nil)
+	}()
+	NewWorkStealingPoolExecutor(0)
+}